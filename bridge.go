@@ -0,0 +1,292 @@
+package liblorago
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+/*
+SPI bridge framing, all integers big endian:
+
+	request:  <op> <mux mode> <mux target> <address> <length:2> <data:length>
+	response: <status> <length:2> <data:length>
+
+length of a request is the data size for w/wb and the size to read for r/rb (no data follows).
+status is BRIDGE_OK with the read data, or BRIDGE_ERR with the error message.
+
+There is no authentication: whoever reaches the server drives the concentrator.
+*/
+
+const (
+	BRIDGE_OP_W  = 0x01
+	BRIDGE_OP_R  = 0x02
+	BRIDGE_OP_WB = 0x03
+	BRIDGE_OP_RB = 0x04
+
+	BRIDGE_OK  = 0x00
+	BRIDGE_ERR = 0x01
+
+	BRIDGE_DEFAULT_PORT = 5678
+
+	BRIDGE_DIAL_TIMEOUT = 5 * time.Second    /* connection to the server */
+	BRIDGE_TIMEOUT      = 2 * time.Second    /* from a request to the end of its response */
+	BRIDGE_IDLE_TIMEOUT = 5 * BRIDGE_TIMEOUT /* server side, a client silent for longer is dropped and the board freed */
+)
+
+/*
+BridgeServer exposes a Transport to remote clients, one client session at a time: a client drives the
+concentrator from its connection to its disconnection, as the HAL state it keeps relies on nobody else
+touching the registers. Clients connecting meanwhile get a BRIDGE_ERR and are disconnected. A client
+that goes silent (paused in a debugger, half-open connection of a crashed host) is dropped after
+BRIDGE_IDLE_TIMEOUT, so that the board goes to the next client.
+*/
+type BridgeServer struct {
+	lock sync.Mutex
+	busy bool /* a client session is running */
+	t    Transport
+	idle time.Duration /* wait for the next request of a client */
+}
+
+func NewBridgeServer(t Transport) *BridgeServer {
+	return &BridgeServer{t: t, idle: BRIDGE_IDLE_TIMEOUT}
+}
+
+/* Serve accepts clients on l until it fails, the Transport is left open */
+func (b *BridgeServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		b.lock.Lock()
+		busy := b.busy
+		b.busy = true
+		b.lock.Unlock()
+		if busy {
+			go b.refuse_conn(conn)
+			continue
+		}
+		go func() {
+			b.serve_conn(conn)
+			b.lock.Lock()
+			b.busy = false
+			b.lock.Unlock()
+		}()
+	}
+}
+
+/* refuse_conn answers the first request of a client with a busy error, the request is read so that closing doesn't reset the connection */
+func (b *BridgeServer) refuse_conn(conn net.Conn) {
+	defer conn.Close()
+	log.Printf("WARNING: SPI bridge busy, client %s refused\n", conn.RemoteAddr())
+	conn.SetDeadline(time.Now().Add(BRIDGE_TIMEOUT))
+	hdr := make([]byte, 6)
+	_, err := io.ReadFull(conn, hdr)
+	if err != nil {
+		return
+	}
+	if hdr[0] == BRIDGE_OP_W || hdr[0] == BRIDGE_OP_WB {
+		_, err = io.CopyN(ioutil.Discard, conn, int64(binary.BigEndian.Uint16(hdr[4:])))
+		if err != nil {
+			return
+		}
+	}
+	msg := []byte("ERROR: SPI BRIDGE BUSY WITH ANOTHER CLIENT\n")
+	resp := make([]byte, 3, 3+len(msg))
+	resp[0] = BRIDGE_ERR
+	binary.BigEndian.PutUint16(resp[1:], uint16(len(msg)))
+	conn.Write(append(resp, msg...))
+}
+
+func (b *BridgeServer) serve_conn(conn net.Conn) {
+	defer conn.Close()
+	log.Printf("INFO: SPI bridge client %s connected\n", conn.RemoteAddr())
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	hdr := make([]byte, 6)
+	for {
+		conn.SetDeadline(time.Now().Add(b.idle))
+		_, err := io.ReadFull(r, hdr)
+		if err != nil {
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				log.Printf("WARNING: SPI bridge client %s idle for %v, disconnected\n", conn.RemoteAddr(), b.idle)
+			} else if err != io.EOF {
+				log.Printf("WARNING: SPI bridge client %s: %v\n", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		/* the rest of the request and the response are due within BRIDGE_TIMEOUT, the SPI transfer included */
+		conn.SetDeadline(time.Now().Add(BRIDGE_TIMEOUT))
+		op, mode, target, addr := hdr[0], hdr[1], hdr[2], hdr[3]
+		length := binary.BigEndian.Uint16(hdr[4:])
+		var data []byte
+		if op == BRIDGE_OP_W || op == BRIDGE_OP_WB {
+			data = make([]byte, length)
+			_, err = io.ReadFull(r, data)
+			if err != nil {
+				log.Printf("WARNING: SPI bridge client %s: %v\n", conn.RemoteAddr(), err)
+				return
+			}
+		}
+
+		switch op {
+		case BRIDGE_OP_W:
+			if length != 1 {
+				err = fmt.Errorf("ERROR: SINGLE WRITE OF %d BYTES\n", length)
+				break
+			}
+			err = b.t.Spi_w(mode, target, addr, data[0])
+			data = nil
+		case BRIDGE_OP_R:
+			var v byte
+			v, err = b.t.Spi_r(mode, target, addr)
+			data = []byte{v}
+		case BRIDGE_OP_WB:
+			err = b.t.Spi_wb(mode, target, addr, data)
+			data = nil
+		case BRIDGE_OP_RB:
+			data, err = b.t.Spi_rb(mode, target, addr, length)
+		default:
+			err = fmt.Errorf("ERROR: UNKNOWN SPI BRIDGE OPERATION %d\n", op)
+		}
+
+		status := byte(BRIDGE_OK)
+		if err != nil {
+			status = BRIDGE_ERR
+			data = []byte(err.Error())
+		}
+		resp := make([]byte, 3, 3+len(data))
+		resp[0] = status
+		binary.BigEndian.PutUint16(resp[1:], uint16(len(data)))
+		resp = append(resp, data...)
+		_, err = w.Write(resp)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Printf("WARNING: SPI bridge client %s: %v\n", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* BridgeClient is a Transport reaching a concentrator through a BridgeServer */
+type BridgeClient struct {
+	lock sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func Lgw_bridge_dial(address string) (*BridgeClient, error) {
+	conn, err := net.DialTimeout("tcp", address, BRIDGE_DIAL_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	return &BridgeClient{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+/* transfer sends one request and reads its response, the connection is dropped on a network error as the stream is out of step */
+func (b *BridgeClient) transfer(op, spi_mux_mode, spi_mux_target, address byte, length uint16, data []byte) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.conn == nil {
+		return nil, fmt.Errorf("ERROR: SPI BRIDGE CLOSED\n")
+	}
+	status, resp, err := b.exchange(op, spi_mux_mode, spi_mux_target, address, length, data)
+	if err != nil {
+		b.conn.Close()
+		b.conn = nil
+		return nil, err
+	}
+	if status != BRIDGE_OK {
+		return nil, errors.New(string(resp))
+	}
+	return resp, nil
+}
+
+func (b *BridgeClient) exchange(op, spi_mux_mode, spi_mux_target, address byte, length uint16, data []byte) (byte, []byte, error) {
+	err := b.conn.SetDeadline(time.Now().Add(BRIDGE_TIMEOUT))
+	if err != nil {
+		return 0, nil, err
+	}
+	req := make([]byte, 6, 6+len(data))
+	req[0] = op
+	req[1] = spi_mux_mode
+	req[2] = spi_mux_target
+	req[3] = address
+	binary.BigEndian.PutUint16(req[4:], length)
+	req = append(req, data...)
+	_, err = b.conn.Write(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	hdr := make([]byte, 3)
+	_, err = io.ReadFull(b.r, hdr)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(hdr[1:]))
+	_, err = io.ReadFull(b.r, resp)
+	if err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], resp, nil
+}
+
+func (b *BridgeClient) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	_, err := b.transfer(BRIDGE_OP_W, spi_mux_mode, spi_mux_target, address, 1, []byte{data})
+	return err
+}
+
+func (b *BridgeClient) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	data, err := b.transfer(BRIDGE_OP_R, spi_mux_mode, spi_mux_target, address, 1, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 1 {
+		return 0, fmt.Errorf("ERROR: SPI BRIDGE RETURNED %d BYTES FOR A SINGLE READ\n", len(data))
+	}
+	return data[0], nil
+}
+
+func (b *BridgeClient) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	if len(data) > 0xFFFF {
+		return fmt.Errorf("ERROR: SPI BRIDGE BURST TOO BIG\n")
+	}
+	_, err := b.transfer(BRIDGE_OP_WB, spi_mux_mode, spi_mux_target, address, uint16(len(data)), data)
+	return err
+}
+
+func (b *BridgeClient) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	data, err := b.transfer(BRIDGE_OP_RB, spi_mux_mode, spi_mux_target, address, size, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(size) {
+		return nil, fmt.Errorf("ERROR: SPI BRIDGE RETURNED %d BYTES INSTEAD OF %d\n", len(data), size)
+	}
+	return data, nil
+}
+
+/* Close drops the connection, the remote Transport stays open for the next client */
+func (b *BridgeClient) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn = nil
+	return err
+}
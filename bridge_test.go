@@ -0,0 +1,131 @@
+package liblorago
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func bridge_listen(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		l.Close()
+	})
+	return l
+}
+
+func TestBridgeSession(t *testing.T) {
+	l := bridge_listen(t)
+	go NewBridgeServer(NewEmulator(false)).Serve(l)
+
+	first, err := Lgw_bridge_dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := first.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err != nil || v != byte(loregs[LGW_VERSION].dflt) {
+		t.Fatalf("version %d, %v", v, err)
+	}
+
+	/* a second client is refused while the first one is connected */
+	second, err := Lgw_bridge_dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = second.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err == nil || !strings.Contains(err.Error(), "BUSY") {
+		t.Errorf("second client got %v", err)
+	}
+	second.Close()
+	_, err = first.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err != nil {
+		t.Errorf("first client broken by the second one: %v", err)
+	}
+
+	/* the next client gets the board once the first one is gone */
+	first.Close()
+	for i := 0; ; i++ {
+		third, err := Lgw_bridge_dial(l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = third.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+		third.Close()
+		if err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("board not released: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+/* a client that stops sending requests is dropped, the next client gets the board */
+func TestBridgeIdleClient(t *testing.T) {
+	l := bridge_listen(t)
+	server := NewBridgeServer(NewEmulator(false))
+	server.idle = 100 * time.Millisecond
+	go server.Serve(l)
+
+	idle, err := Lgw_bridge_dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	_, err = idle.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	next, err := Lgw_bridge_dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+	_, err = next.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err != nil {
+		t.Errorf("board kept by an idle client: %v", err)
+	}
+	_, err = idle.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err == nil {
+		t.Error("idle client still connected")
+	}
+}
+
+func TestBridgeTimeout(t *testing.T) {
+	l := bridge_listen(t)
+	go func() {
+		/* a server that never answers */
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	b, err := Lgw_bridge_dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin := time.Now()
+	_, err = b.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err == nil {
+		t.Fatal("read from a silent server succeeded")
+	}
+	if time.Since(begin) > BRIDGE_TIMEOUT+time.Second {
+		t.Errorf("read gave up after %v", time.Since(begin))
+	}
+	_, err = b.Spi_r(LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, 0x01)
+	if err == nil || !strings.Contains(err.Error(), "CLOSED") {
+		t.Errorf("connection kept after a timeout: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package liblorago
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	CAL_TIMEOUT = 5 * time.Second       /* calibration measured between 2.1 and 2.2 s */
+	CAL_POLL    = 10 * time.Millisecond /* calibration status polling period */
+)
+
+/* CalibrationResult is the status of the calibration firmware (LGW_MCU_AGC_STATUS), decoded */
+type CalibrationResult struct {
+	Status             uint8                 /* raw status */
+	Finished           bool                  /* bit 7: calibration finished */
+	Sx1301_access      bool                  /* bit 0: could access the SX1301 registers */
+	Radio_access       [LGW_RF_CHAIN_NB]bool /* bits 1, 2: could access the radio A, B registers */
+	Rx_image_rejection [LGW_RF_CHAIN_NB]bool /* bits 3, 4: radio A, B RX image rejection successful */
+	Tx_dc_offset       [LGW_RF_CHAIN_NB]bool /* bits 5, 6: radio A, B TX DC offset correction successful */
+	Duration           time.Duration         /* from the calibration start to its end, or to the timeout */
+}
+
+func Lgw_calibration_decode(status uint8) CalibrationResult {
+	bit := func(n byte) bool {
+		return TAKE_N_BITS_FROM(status, n, 1) == 1
+	}
+	return CalibrationResult{
+		Status:             status,
+		Finished:           bit(7),
+		Sx1301_access:      bit(0),
+		Radio_access:       [LGW_RF_CHAIN_NB]bool{bit(1), bit(2)},
+		Rx_image_rejection: [LGW_RF_CHAIN_NB]bool{bit(3), bit(4)},
+		Tx_dc_offset:       [LGW_RF_CHAIN_NB]bool{bit(5), bit(6)},
+	}
+}
+
+/* check tells if the calibration went well for the radios enabled in s */
+func (r *CalibrationResult) check(s *State) error {
+	if !r.Finished || !r.Sx1301_access {
+		return fmt.Errorf("ERROR: CALIBRATION FAILURE (STATUS = %d)\n", r.Status)
+	}
+	radio := [LGW_RF_CHAIN_NB]string{"A", "B"}
+	for i := 0; i < LGW_RF_CHAIN_NB; i++ {
+		if s.rf_enable[i] && !r.Radio_access[i] {
+			return fmt.Errorf("WARNING: calibration could not access radio %s\n", radio[i])
+		}
+	}
+	for i := 0; i < LGW_RF_CHAIN_NB; i++ {
+		if s.rf_enable[i] && !r.Rx_image_rejection[i] {
+			return fmt.Errorf("WARNING: problem in calibration of radio %s for image rejection\n", radio[i])
+		}
+	}
+	for i := 0; i < LGW_RF_CHAIN_NB; i++ {
+		if s.rf_enable[i] && s.rf_tx_enable[i] && !r.Tx_dc_offset[i] {
+			return fmt.Errorf("WARNING: problem in calibration of radio %s for TX DC offset\n", radio[i])
+		}
+	}
+	return nil
+}
+
+/*
+lgw_calibration_wait polls the calibration status until its bit 7 tells the calibration is finished, or timeout
+is over, then takes back the control of the registers from the MCU. The status register is common to all pages,
+so polling it never switches the page under the MCU. On timeout the result holds the last status read.
+*/
+func lgw_calibration_wait(ctx context.Context, c *Concentrator, timeout time.Duration) (*CalibrationResult, error) {
+	if timeout == 0 {
+		timeout = CAL_TIMEOUT
+	}
+	begin := time.Now()
+	for {
+		err := sleep_ctx(ctx, CAL_POLL)
+		if err != nil {
+			Lgw_reg_w(c, LGW_EMERGENCY_FORCE_HOST_CTRL, 1) /* take back control before giving up */
+			return nil, err
+		}
+		read_val, err := Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
+		if err != nil {
+			return nil, err
+		}
+		if (read_val&0x80 != 0) || (time.Since(begin) >= timeout) {
+			break
+		}
+	}
+	duration := time.Since(begin)
+
+	err := Lgw_reg_w(c, LGW_EMERGENCY_FORCE_HOST_CTRL, 1) /* Take back control */
+	if err != nil {
+		return nil, err
+	}
+	read_val, err := Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
+	if err != nil {
+		return nil, err
+	}
+	r := Lgw_calibration_decode(uint8(read_val))
+	r.Duration = duration
+	if !r.Finished {
+		return &r, fmt.Errorf("ERROR: CALIBRATION TIMEOUT AFTER %d ms (STATUS = %d)\n", timeout/time.Millisecond, r.Status)
+	}
+	return &r, nil
+}
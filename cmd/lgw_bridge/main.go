@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/tkiraly/liblorago"
+)
+
+/*
+lgw_bridge exposes the concentrator SPI link of this host over TCP. The bridge has no authentication,
+whoever reaches it drives the radio, so it listens on the loopback by default: reach it through an SSH
+tunnel, or give -listen an address only trusted hosts can reach.
+*/
+func main() {
+	spi := flag.String("spi", "/dev/spidev0.0", "spidev device of the concentrator")
+	listen := flag.String("listen", fmt.Sprintf("127.0.0.1:%d", liblorago.BRIDGE_DEFAULT_PORT), "address to listen on, unauthenticated: anyone reaching it drives the radio")
+	emulate := flag.Bool("emulate", false, "serve the register emulator instead of a spidev")
+	fpga := flag.Bool("fpga", false, "with -emulate, emulate a board with an FPGA")
+	speed := flag.Uint("speed", liblorago.SPI_SPEED, "SPI clock, Hz")
+	chunk := flag.Uint("chunk", liblorago.LGW_BURST_CHUNK, "largest number of data bytes in one SPI message")
+	flag.Parse()
+
+	var t liblorago.Transport
+	if *emulate {
+		t = liblorago.NewEmulator(*fpga)
+	} else {
+		d, err := liblorago.Lgw_spi_open_opts(*spi, liblorago.Spi_options{Speed_hz: uint32(*speed), Chunk_size: uint32(*chunk)})
+		if err != nil {
+			log.Fatal(err)
+		}
+		t = d
+	}
+
+	err := serve(t, *listen)
+	t.Close() /* log.Fatal doesn't run deferred calls */
+	log.Fatal(err)
+}
+
+func serve(t liblorago.Transport, listen string) error {
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	log.Printf("INFO: SPI bridge listening on %s\n", l.Addr())
+	return liblorago.NewBridgeServer(t).Serve(l)
+}
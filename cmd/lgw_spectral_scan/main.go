@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tkiraly/liblorago"
+)
+
+/* lgw_spectral_scan writes the RSSI histogram of each scanned frequency as a CSV line: freq,rssi,count,rssi,count... */
+func main() {
+	spi := flag.String("spi", "/dev/spidev0.0", "spidev device of the concentrator")
+	bridge := flag.String("bridge", "", "host:port of an lgw_bridge to use instead of a spidev")
+	emulate := flag.Bool("emulate", false, "scan the register emulator instead of a board")
+	speed := flag.Uint("speed", liblorago.SPI_SPEED, "SPI clock, Hz")
+	start := flag.Uint("start", 863100000, "start frequency, Hz")
+	stop := flag.Uint("stop", 870000000, "stop frequency, Hz")
+	step := flag.Uint("step", 200000, "frequency step, Hz")
+	nb := flag.Uint("n", 65535, "number of RSSI reads per frequency")
+	offset := flag.Int("offset", -4, "SX127x RSSI offset, dB")
+	out := flag.String("out", "rssi_histogram.csv", "CSV file, - for stdout")
+	flag.Parse()
+
+	if *nb == 0 || *nb > 65535 {
+		log.Fatal("number of RSSI reads must be 1 to 65535")
+	}
+
+	var c *liblorago.Concentrator
+	switch {
+	case *emulate:
+		c = liblorago.NewConcentratorTransport(liblorago.NewEmulator(true), nil)
+	case *bridge != "":
+		c = liblorago.NewConcentratorBridge(*bridge, nil)
+	default:
+		c = liblorago.NewConcentratorOptions(*spi, liblorago.Spi_options{Speed_hz: uint32(*speed)}, nil)
+	}
+	err := c.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	results, err := c.SpectralScan(uint32(*start), uint32(*stop), uint32(*step), uint16(*nb), int8(*offset))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f := os.Stdout
+	if *out != "-" {
+		f, err = os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range results {
+		fmt.Fprintf(w, "%d", r.Freq_hz)
+		for i := range r.Rssi {
+			fmt.Fprintf(w, ",%.1f,%d", r.Rssi[i], r.Count[i])
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	err = w.Flush()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,213 @@
+package liblorago
+
+import (
+	"context"
+	"sync"
+)
+
+//NOTE: a Concentrator is the handle of one SX1301 board, it owns everything libloragw keeps in static variables at runtime
+//NOTE: its methods are safe for concurrent use, each one runs as a whole before the next starts; the Lgw_* functions are not
+type Concentrator struct {
+	lock sync.Mutex /* held by the methods for the whole operation, page switches and register accesses included */
+
+	open         func() (Transport, error) /* opens the SPI link, called on every start */
+	borrowed     bool                      /* open hands out the caller's Transport, it is left open on disconnect */
+	transport    Transport                 /* opened SPI link, nil when disconnected */
+	spi_mux_mode byte                      /* LGW_SPI_MUX_MODE0 without FPGA, LGW_SPI_MUX_MODE1 with FPGA */
+	page         int8                      /* SX1301 register page selected, -1 when unknown */
+	mcu_reg_ctrl bool                      /* the MCU was given control of the registers (LGW_EMERGENCY_FORCE_HOST_CTRL = 0) */
+	state        *State                    /* parsed configuration */
+
+	/* TX I/Q imbalance coefficients for mixer gain = 8 to 15 */
+	cal_offset_a_i [8]int8 /* TX I offset for radio A */
+	cal_offset_a_q [8]int8 /* TX Q offset for radio A */
+	cal_offset_b_i [8]int8 /* TX I offset for radio B */
+	cal_offset_b_q [8]int8 /* TX Q offset for radio B */
+
+	fpga             *FPGAInfo /* FPGA found at connection, nil without FPGA */
+	tx_notch_support byte      /* 1 if the FPGA provides a TX notch filter */
+	tx_notch_offset  byte      /* TX notch filter frequency offset as programmed in the FPGA */
+
+	board *Board_identity /* identity read from the board EEPROM at start, nil if none */
+
+	calibration *CalibrationResult /* result of the last calibration, nil if none */
+
+	lbt_start_freq uint32 /* lowest LBT channel frequency supported by the FPGA */
+
+	is_started bool
+}
+
+func NewConcentrator(path string, s *State) *Concentrator {
+	return &Concentrator{
+		open: func() (Transport, error) {
+			return Lgw_spi_open(path)
+		},
+		state: s,
+	}
+}
+
+/* NewConcentratorOptions drives a concentrator on the spidev at path, with the link parameters of opts */
+func NewConcentratorOptions(path string, opts Spi_options, s *State) *Concentrator {
+	return &Concentrator{
+		open: func() (Transport, error) {
+			return Lgw_spi_open_opts(path, opts)
+		},
+		state: s,
+	}
+}
+
+/*
+NewConcentratorTransport drives a concentrator over an already available Transport. Stop and Disconnect
+leave it open so the concentrator can be started again, the caller closes it when done with it.
+*/
+func NewConcentratorTransport(t Transport, s *State) *Concentrator {
+	return &Concentrator{
+		open: func() (Transport, error) {
+			return t, nil
+		},
+		borrowed: true,
+		state:    s,
+	}
+}
+
+/* NewConcentratorBridge drives a concentrator exposed by a BridgeServer at address (host:port) */
+func NewConcentratorBridge(address string, s *State) *Concentrator {
+	return &Concentrator{
+		open: func() (Transport, error) {
+			return Lgw_bridge_dial(address)
+		},
+		state: s,
+	}
+}
+
+/* Connect opens the SPI link and configures the FPGA without starting the radios, for tools like the spectral scan */
+func (c *Concentrator) Connect() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_connect(c, false, LGW_DEFAULT_NOTCH_FREQ)
+}
+
+func (c *Concentrator) Disconnect() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_disconnect(c)
+}
+
+func (c *Concentrator) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_start(c)
+}
+
+/* StartContext is Start, giving up when ctx is done and reporting each step to progress if not nil, see Lgw_start_ctx */
+func (c *Concentrator) StartContext(ctx context.Context, progress func(Start_event)) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_start_ctx(ctx, c, progress)
+}
+
+func (c *Concentrator) Stop() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_stop(c)
+}
+
+func (c *Concentrator) Receive() ([]Lgw_pkt_rx_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_receive(c)
+}
+
+func (c *Concentrator) Send(pkt_data Lgw_pkt_tx_s) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_send(c, pkt_data)
+}
+
+func (c *Concentrator) Status(sel byte) (byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_status(c, sel)
+}
+
+func (c *Concentrator) Trigcnt() (uint32, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_get_trigcnt(c)
+}
+
+func (c *Concentrator) Instcnt() (uint32, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_get_instcnt(c)
+}
+
+func (c *Concentrator) SpectralScan(start_freq, stop_freq, step_freq uint32, nb_read uint16, rssi_offset int8) ([]Spectral_scan_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_spectral_scan(c, start_freq, stop_freq, step_freq, nb_read, rssi_offset)
+}
+
+func (c *Concentrator) DumpRegisters() ([]Lgw_reg_dump_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_reg_dump(c)
+}
+
+func (c *Concentrator) DumpFpgaRegisters() ([]Lgw_reg_dump_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_fpga_reg_dump(c)
+}
+
+func (c *Concentrator) Snapshot() (*Lgw_reg_snapshot_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_reg_snapshot(c)
+}
+
+func (c *Concentrator) Restore(snap *Lgw_reg_snapshot_s) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_reg_restore(c, snap)
+}
+
+/* SelfTest checks the registers against their defaults and runs the BIST, on a connected but not started board */
+func (c *Concentrator) SelfTest() (*Lgw_reg_check_s, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_reg_check(c, true)
+}
+
+/* Board returns the identity read from the board EEPROM at start, nil if the board has none */
+func (c *Concentrator) Board() *Board_identity {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.board
+}
+
+/* Calibration returns the result of the last calibration, also after a failed one, nil if the last start didn't get that far */
+func (c *Concentrator) Calibration() *CalibrationResult {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calibration
+}
+
+/* FPGA returns the FPGA found at connection, nil if the board has none */
+func (c *Concentrator) FPGA() *FPGAInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.fpga
+}
+
+func (c *Concentrator) SetTxNotchFreq(tx_notch_freq uint32) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Lgw_fpga_set_tx_notch_freq(c, tx_notch_freq)
+}
+
+func (c *Concentrator) SpiMuxMode() byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.spi_mux_mode
+}
@@ -0,0 +1,84 @@
+package liblorago
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+/* receive, TX and stats goroutines share one concentrator, run with -race */
+func TestConcurrentUse(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		s, err := ParseConfig("testdata/global_conf.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := NewEmulator(with_fpga)
+		c := NewConcentratorTransport(e, s)
+		err = c.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Stop()
+
+		const nb_pkt = 300
+		var wg sync.WaitGroup
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < nb_pkt; i++ {
+				e.Inject_lora_packet(0, 7, CR_LORA_4_5, true, 100, 7.5, uint32(i), []byte(fmt.Sprint(i)))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			got := 0
+			for deadline := time.Now().Add(10 * time.Second); (got < nb_pkt) && time.Now().Before(deadline); {
+				p, err := c.Receive()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for _, q := range p {
+					if string(q.Payload[:q.Size]) != fmt.Sprint(got) {
+						t.Errorf("packet %q received instead of %d", q.Payload[:q.Size], got)
+						return
+					}
+					got++
+				}
+			}
+			if got != nb_pkt {
+				t.Errorf("received %d packets, want %d", got, nb_pkt)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				err := c.Send(lora_tx(IMMEDIATE, 0, []byte{1, 2, 3}))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_, err := c.Status(TX_STATUS)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				_, err = c.Trigcnt()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				c.FPGA()
+				c.Calibration()
+			}
+		}()
+		wg.Wait()
+	})
+}
@@ -0,0 +1,178 @@
+package liblorago
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	LGW_EEPROM_SIZE = 128 /* addressable through the 7 bit address of the SPI mux header */
+
+	BOARD_ID_MAGIC   = "LGWB"
+	BOARD_ID_VERSION = 1
+	BOARD_ID_SIZE    = 4 + 1 + 1 + 8 + 2*LGW_RF_CHAIN_NB + 1 + 5*TX_GAIN_LUT_SIZE_MAX + 4
+)
+
+/*
+The EEPROM is reached through the SPI mux header with the same framing as the SX1301 registers: mux target,
+R/W bit and 7 bit address, then the data. This framing is defined by this library, it assumes the FPGA presents
+the EEPROM as a 128 byte register space. It is not the command set of a bare SPI EEPROM (READ 0x03, WRITE 0x02,
+WREN 0x06, 25AA/25LC style), a board routing the mux target straight to such a part needs that protocol instead.
+*/
+
+/* Lgw_eeprom_r reads size bytes of the board EEPROM from address, only boards with an FPGA have one */
+func Lgw_eeprom_r(c *Concentrator, address byte, size uint16) ([]byte, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return nil, fmt.Errorf("ERROR: NO FPGA, EEPROM NOT REACHABLE\n")
+	}
+	if int(address)+int(size) > LGW_EEPROM_SIZE {
+		return nil, fmt.Errorf("ERROR: EEPROM READ OUT OF RANGE\n")
+	}
+	return c.transport.Spi_rb(LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_EEPROM, address, size)
+}
+
+/* Lgw_eeprom_w writes data to the board EEPROM from address */
+func Lgw_eeprom_w(c *Concentrator, address byte, data []byte) error {
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return fmt.Errorf("ERROR: NO FPGA, EEPROM NOT REACHABLE\n")
+	}
+	if int(address)+len(data) > LGW_EEPROM_SIZE {
+		return fmt.Errorf("ERROR: EEPROM WRITE OUT OF RANGE\n")
+	}
+	return c.transport.Spi_wb(LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_EEPROM, address, data)
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+type Board_tx_gain struct {
+	Dig_gain uint8 /* 2 bits, control of the digital gain of SX1301 */
+	Pa_gain  uint8 /* 2 bits, control of the external PA (SX1301 I/O) */
+	Dac_gain uint8 /* 2 bits, control of the radio DAC */
+	Mix_gain uint8 /* 4 bits, control of the radio mixer */
+	Rf_power int8  /* measured TX power at the board connector, in dBm */
+}
+
+/*
+Board_identity is the per-board calibration kept at the start of the EEPROM. The record is defined by this
+library, it is not a Semtech format: only boards written with Lgw_board_identity_w hold one, the EEPROM of
+other boards reads as blank. Layout, little endian:
+
+	0   magic "LGWB"
+	4   layout version
+	5   board type
+	6   serial number (8 bytes)
+	14  RSSI offset of each radio, int16 in 0.1 dB
+	18  number of TX gain LUT entries
+	19  TX gain LUT, 16 entries of dig, pa, dac, mix gain and rf power
+	99  CRC32 (IEEE) of the previous bytes
+*/
+type Board_identity struct {
+	Board_type  byte
+	Serial      uint64
+	Rssi_offset [LGW_RF_CHAIN_NB]float64
+	Tx_gain     []Board_tx_gain
+}
+
+func (b *Board_identity) Encode() ([]byte, error) {
+	if len(b.Tx_gain) > TX_GAIN_LUT_SIZE_MAX {
+		return nil, fmt.Errorf("ERROR: TOO MANY TX GAIN LUT ENTRIES\n")
+	}
+	buff := make([]byte, BOARD_ID_SIZE)
+	copy(buff, BOARD_ID_MAGIC)
+	buff[4] = BOARD_ID_VERSION
+	buff[5] = b.Board_type
+	binary.LittleEndian.PutUint64(buff[6:], b.Serial)
+	for i, o := range b.Rssi_offset {
+		binary.LittleEndian.PutUint16(buff[14+2*i:], uint16(int16(o*10)))
+	}
+	buff[18] = byte(len(b.Tx_gain))
+	for i, g := range b.Tx_gain {
+		e := buff[19+5*i:]
+		e[0] = g.Dig_gain
+		e[1] = g.Pa_gain
+		e[2] = g.Dac_gain
+		e[3] = g.Mix_gain
+		e[4] = byte(g.Rf_power)
+	}
+	binary.LittleEndian.PutUint32(buff[BOARD_ID_SIZE-4:], crc32.ChecksumIEEE(buff[:BOARD_ID_SIZE-4]))
+	return buff, nil
+}
+
+/* Decode_board_identity returns nil without error when buff holds no identity (blank EEPROM) */
+func Decode_board_identity(buff []byte) (*Board_identity, error) {
+	if len(buff) < BOARD_ID_SIZE || string(buff[:4]) != BOARD_ID_MAGIC {
+		return nil, nil
+	}
+	if buff[4] != BOARD_ID_VERSION {
+		return nil, fmt.Errorf("ERROR: UNSUPPORTED BOARD IDENTITY VERSION %d\n", buff[4])
+	}
+	if binary.LittleEndian.Uint32(buff[BOARD_ID_SIZE-4:]) != crc32.ChecksumIEEE(buff[:BOARD_ID_SIZE-4]) {
+		return nil, fmt.Errorf("ERROR: BOARD IDENTITY CRC MISMATCH\n")
+	}
+	if buff[18] > TX_GAIN_LUT_SIZE_MAX {
+		return nil, fmt.Errorf("ERROR: BOARD IDENTITY HAS %d TX GAIN LUT ENTRIES\n", buff[18])
+	}
+	b := &Board_identity{
+		Board_type: buff[5],
+		Serial:     binary.LittleEndian.Uint64(buff[6:]),
+		Tx_gain:    make([]Board_tx_gain, buff[18]),
+	}
+	for i := range b.Rssi_offset {
+		b.Rssi_offset[i] = float64(int16(binary.LittleEndian.Uint16(buff[14+2*i:]))) / 10
+	}
+	for i := range b.Tx_gain {
+		e := buff[19+5*i:]
+		b.Tx_gain[i] = Board_tx_gain{
+			Dig_gain: e[0],
+			Pa_gain:  e[1],
+			Dac_gain: e[2],
+			Mix_gain: e[3],
+			Rf_power: int8(e[4]),
+		}
+	}
+	return b, nil
+}
+
+/* Lgw_board_identity reads the board identity, nil without error if the board has none */
+func Lgw_board_identity(c *Concentrator) (*Board_identity, error) {
+	buff, err := Lgw_eeprom_r(c, 0, BOARD_ID_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	return Decode_board_identity(buff)
+}
+
+func Lgw_board_identity_w(c *Concentrator, b *Board_identity) error {
+	buff, err := b.Encode()
+	if err != nil {
+		return err
+	}
+	return Lgw_eeprom_w(c, 0, buff)
+}
+
+/* apply overrides the RSSI offsets and, when the board has one, the TX gain LUT of the configuration */
+func (b *Board_identity) apply(s *State) {
+	s.rf_rssi_offset = b.Rssi_offset
+	if len(b.Tx_gain) == 0 {
+		return
+	}
+	s.txgain_lut.size = uint8(len(b.Tx_gain))
+	s.txgain_lut.lut = [TX_GAIN_LUT_SIZE_MAX]lgw_tx_gain_s{}
+	for i, g := range b.Tx_gain {
+		s.txgain_lut.lut[i] = lgw_tx_gain_s{
+			dig_gain: g.Dig_gain,
+			pa_gain:  g.Pa_gain,
+			dac_gain: g.Dac_gain,
+			mix_gain: g.Mix_gain,
+			rf_power: g.Rf_power,
+		}
+	}
+	fmt.Printf("INFO: using calibration of board type %d serial %016X from EEPROM\n", b.Board_type, b.Serial)
+}
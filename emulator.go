@@ -0,0 +1,813 @@
+package liblorago
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	EMU_PROM_SIZE    = 8192 /* size of the MCU program RAM window */
+	EMU_MCU_RAM_SIZE = 256  /* size of the MCU data RAM seen through the debug registers */
+	EMU_TX_BUF_SIZE  = 512  /* size of the TX data buffer */
+	EMU_FPGA_VERSION = 33   /* FPGA version reported by the emulated FPGA */
+	EMU_SX125X_VER   = 0x21 /* version reported by the emulated SX125x radios */
+)
+
+/* firmware images the emulated MCUs know how to "run" */
+const (
+	emu_fw_none = iota
+	emu_fw_cal
+	emu_fw_agc
+	emu_fw_arb
+)
+
+type emu_pkt struct {
+	status byte   /* FIFO CRC status */
+	data   []byte /* payload followed by RX_METADATA_NB bytes of metadata */
+}
+
+/*
+Emulator is an in-memory SX1301 (and optionally its FPGA) that implements Transport.
+Registers follow loregs and fpga_regs: paging, bit fields, defaults and read-only bits.
+The MCUs are faked: loading one of the embedded firmwares and releasing the MCU from reset
+answers the version checks, the calibration and the AGC handshakes done by Lgw_start.
+*/
+type Emulator struct {
+	lock sync.Mutex
+
+	common    [128]byte    /* registers available on all pages */
+	pages     [4][128]byte /* paged registers */
+	ro_common [128]byte    /* read-only bit masks */
+	ro_pages  [4][128]byte
+
+	fpga      bool
+	fpga_mem  [128]byte
+	fpga_ro   [128]byte
+	fpga_feat byte /* feature bits reported in LGW_FPGA_FEATURE */
+	eeprom    [LGW_EEPROM_SIZE]byte
+	sx127x    [128]byte /* auxiliary radio behind the FPGA */
+	lbt_busy  [LBT_CHANNEL_FREQ_NB]bool
+	lbt_free  [LBT_CHANNEL_FREQ_NB]uint32 /* counter value when a busy channel was last free */
+	histo     [2 * SPECTRAL_SCAN_RSSI_RANGE]byte
+	histo_ptr int
+	spectrum  map[uint32]float64 /* RSSI per frequency seen by the spectral scan */
+
+	bist_fail map[Lgw_reg_id]bool /* RAMs failing their BIST, by *_BIST_STATUS register */
+
+	prom     [EMU_PROM_SIZE]byte /* host window on the MCU program RAM */
+	prom_ptr int
+	image    [2]int /* firmware held by each MCU, indexed by MCU_ARB/MCU_AGC */
+	running  [2]bool
+	agc_ram  [EMU_MCU_RAM_SIZE]byte
+	arb_ram  [EMU_MCU_RAM_SIZE]byte
+
+	agc_wait    bool /* AGC firmware waits for a command after AGC_CMD_WAIT */
+	agc_phase   int  /* 0: TX gain LUT, 1: TX freq MSBs, 2: chan_select option, 3: radio select, 4: running */
+	agc_lut_idx int
+	cal_status  byte /* forced calibration status, 0 to compute it from the calibration command */
+	cal_offsets [32]int8
+
+	radios   [LGW_RF_CHAIN_NB][128]byte
+	radio_cs [LGW_RF_CHAIN_NB]bool
+
+	fifo   []emu_pkt
+	rx_ptr int
+
+	tx_buf [EMU_TX_BUF_SIZE]byte
+	tx_ptr int
+	tx_len int
+
+	epoch    time.Time
+	trig_cnt uint32
+}
+
+func NewEmulator(with_fpga bool) *Emulator {
+	e := &Emulator{fpga: with_fpga, fpga_feat: 0x07}
+	/* read-only bits never change, compute them once */
+	for _, r := range loregs {
+		emu_field_bits(r, func(addr byte, mask byte) {
+			if r.rdon == 1 {
+				*e.ro_at(r.page, addr) |= mask
+			}
+		})
+	}
+	for _, r := range fpga_regs {
+		emu_field_bits(r, func(addr byte, mask byte) {
+			if r.rdon == 1 {
+				e.fpga_ro[addr&0x7F] |= mask
+			}
+		})
+	}
+	for i := range e.radios {
+		e.radios[i][0x07] = EMU_SX125X_VER
+		e.radios[i][0x11] = 0x03 /* PLLs always locked */
+	}
+	e.sx127x[SX127X_REG_VERSION] = SX1272_VERSION
+	e.reset()
+	e.fpga_reset()
+	return e
+}
+
+/* calls fn for each byte a register spans, with the mask of its bits in that byte */
+func emu_field_bits(r Lgw_reg_s, fn func(addr byte, mask byte)) {
+	for k := 0; k < int(r.leng); k++ {
+		bit := int(r.offs) + k
+		fn(r.addr+byte(bit/8), 1<<uint(bit%8))
+	}
+}
+
+func emu_is_common(addr byte) bool {
+	return (addr <= 32) || (addr >= 125)
+}
+
+func (e *Emulator) mem_at(page int8, addr byte) *byte {
+	addr &= 0x7F
+	if page < 0 || emu_is_common(addr) {
+		return &e.common[addr]
+	}
+	return &e.pages[page&PAGE_MASK][addr]
+}
+
+func (e *Emulator) ro_at(page int8, addr byte) *byte {
+	addr &= 0x7F
+	if page < 0 || emu_is_common(addr) {
+		return &e.ro_common[addr]
+	}
+	return &e.ro_pages[page&PAGE_MASK][addr]
+}
+
+func (e *Emulator) page() int8 {
+	return int8(e.common[PAGE_ADDR] & PAGE_MASK)
+}
+
+/* internal field accessors, they bypass the read-only protection */
+func emu_get(r Lgw_reg_s, at func(int8, byte) *byte) int32 {
+	var u uint32
+	for k := int(r.leng) - 1; k >= 0; k-- {
+		bit := int(r.offs) + k
+		u <<= 1
+		if *at(r.page, r.addr+byte(bit/8))&(1<<uint(bit%8)) != 0 {
+			u |= 1
+		}
+	}
+	if r.sign == 1 && r.leng < 32 && u&(1<<(r.leng-1)) != 0 {
+		return int32(u) - (1 << r.leng)
+	}
+	return int32(u)
+}
+
+func emu_set(r Lgw_reg_s, at func(int8, byte) *byte, value int32) {
+	u := uint32(value)
+	for k := 0; k < int(r.leng); k++ {
+		bit := int(r.offs) + k
+		p := at(r.page, r.addr+byte(bit/8))
+		if u&(1<<uint(k)) != 0 {
+			*p |= 1 << uint(bit%8)
+		} else {
+			*p &^= 1 << uint(bit%8)
+		}
+	}
+}
+
+func (e *Emulator) get(register_id Lgw_reg_id) int32 {
+	return emu_get(loregs[register_id], e.mem_at)
+}
+
+func (e *Emulator) set(register_id Lgw_reg_id, value int32) {
+	emu_set(loregs[register_id], e.mem_at, value)
+}
+
+func (e *Emulator) fpga_at(page int8, addr byte) *byte {
+	return &e.fpga_mem[addr&0x7F]
+}
+
+func (e *Emulator) hit(register_id Lgw_reg_id, page int8, addr byte) bool {
+	r := loregs[register_id]
+	return (r.addr == addr) && ((r.page == -1) || emu_is_common(addr) || (r.page == page))
+}
+
+/* put back every register to its default value, as a soft reset does */
+func (e *Emulator) reset() {
+	e.common = [128]byte{}
+	e.pages = [4][128]byte{}
+	for i := Lgw_reg_id(0); i < LGW_TOTALREGS; i++ {
+		if i == LGW_TX_TRIG_ALL {
+			continue /* alias of the TX_TRIG_* bits */
+		}
+		e.set(i, loregs[i].dflt)
+	}
+	e.running = [2]bool{}
+	e.agc_wait = false
+	e.agc_phase = 0
+	e.agc_lut_idx = 0
+	e.radio_cs = [LGW_RF_CHAIN_NB]bool{}
+	e.tx_ptr = 0
+	e.tx_len = 0
+	e.epoch = time.Now()
+}
+
+func (e *Emulator) fpga_reset() {
+	e.fpga_mem = [128]byte{}
+	for _, r := range fpga_regs {
+		emu_set(r, e.fpga_at, r.dflt)
+	}
+	emu_set(fpga_regs[LGW_FPGA_VERSION], e.fpga_at, EMU_FPGA_VERSION)
+	emu_set(fpga_regs[LGW_FPGA_FEATURE], e.fpga_at, int32(e.fpga_feat))
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* Transport implementation */
+
+func (e *Emulator) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.write(spi_mux_mode, spi_mux_target, address&0x7F, data)
+	return nil
+}
+
+func (e *Emulator) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.read(spi_mux_mode, spi_mux_target, address&0x7F, false), nil
+}
+
+func (e *Emulator) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	address &= 0x7F
+	for _, d := range data {
+		e.write(spi_mux_mode, spi_mux_target, address, d)
+		if !e.is_stream(spi_mux_mode, spi_mux_target, address) {
+			address = (address + 1) & 0x7F
+		}
+	}
+	return nil
+}
+
+func (e *Emulator) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	address &= 0x7F
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = e.read(spi_mux_mode, spi_mux_target, address, true)
+		if !e.is_stream(spi_mux_mode, spi_mux_target, address) {
+			address = (address + 1) & 0x7F
+		}
+	}
+	return data, nil
+}
+
+/* the emulated board keeps its state when closed, so that it can be started again */
+func (e *Emulator) Close() error {
+	return nil
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* data ports that do not auto-increment the address during bursts */
+func (e *Emulator) is_stream(spi_mux_mode, spi_mux_target, addr byte) bool {
+	if spi_mux_mode == LGW_SPI_MUX_MODE1 && spi_mux_target == LGW_SPI_MUX_TARGET_FPGA {
+		return e.fpga && addr == fpga_regs[LGW_FPGA_HISTO_RAM_DATA].addr
+	}
+	if spi_mux_mode == LGW_SPI_MUX_MODE1 && spi_mux_target != LGW_SPI_MUX_TARGET_SX1301 {
+		return false /* EEPROM and SX127x auto-increment */
+	}
+	p := e.page()
+	return e.hit(LGW_RX_DATA_BUF_DATA, p, addr) || e.hit(LGW_TX_DATA_BUF_DATA, p, addr) ||
+		e.hit(LGW_MCU_PROM_DATA, p, addr) || e.hit(LGW_CAPTURE_RAM_DATA, p, addr)
+}
+
+func (e *Emulator) write(spi_mux_mode, spi_mux_target, addr, data byte) {
+	if spi_mux_mode == LGW_SPI_MUX_MODE1 {
+		switch spi_mux_target {
+		case LGW_SPI_MUX_TARGET_SX1301:
+		case LGW_SPI_MUX_TARGET_FPGA:
+			if e.fpga {
+				e.fpga_write(addr, data)
+			}
+			return
+		case LGW_SPI_MUX_TARGET_EEPROM:
+			if e.fpga {
+				e.eeprom[addr] = data
+			}
+			return
+		case LGW_SPI_MUX_TARGET_SX127X:
+			if e.fpga {
+				e.sx127x_write(addr, data)
+			}
+			return
+		default:
+			return
+		}
+	}
+	e.sx1301_write(addr, data)
+}
+
+func (e *Emulator) read(spi_mux_mode, spi_mux_target, addr byte, burst bool) byte {
+	if spi_mux_mode == LGW_SPI_MUX_MODE1 {
+		switch spi_mux_target {
+		case LGW_SPI_MUX_TARGET_SX1301:
+		case LGW_SPI_MUX_TARGET_FPGA:
+			if e.fpga {
+				return e.fpga_read(addr)
+			}
+			/* without FPGA, the SX1301 sees the mux header as a burst read command and */
+			/* the last byte of the frame is clocked out of the following register */
+			return e.sx1301_read((spi_mux_target+1)&0x7F, false)
+		case LGW_SPI_MUX_TARGET_EEPROM:
+			if e.fpga {
+				return e.eeprom[addr]
+			}
+			return 0
+		case LGW_SPI_MUX_TARGET_SX127X:
+			if e.fpga {
+				return e.sx127x[addr]
+			}
+			return 0
+		default:
+			return 0
+		}
+	}
+	return e.sx1301_read(addr, burst)
+}
+
+func (e *Emulator) fpga_write(addr, data byte) {
+	started := emu_get(fpga_regs[LGW_FPGA_CTRL_FEATURE_START], e.fpga_at) == 1
+	p := &e.fpga_mem[addr]
+	*p = (*p & e.fpga_ro[addr]) | (data &^ e.fpga_ro[addr])
+	if emu_get(fpga_regs[LGW_FPGA_SOFT_RESET], e.fpga_at) == 1 {
+		e.fpga_reset()
+		return
+	}
+
+	switch addr {
+	case fpga_regs[LGW_FPGA_CTRL_FEATURE_START].addr:
+		start := emu_get(fpga_regs[LGW_FPGA_CTRL_FEATURE_START], e.fpga_at) == 1
+		if start && !started {
+			e.histogram()
+		} else if !start {
+			emu_set(fpga_regs[LGW_FPGA_STATUS], e.fpga_at, 0)
+		}
+		if emu_get(fpga_regs[LGW_FPGA_CTRL_CLEAR_HISTO_MEM], e.fpga_at) == 1 {
+			e.histo = [2 * SPECTRAL_SCAN_RSSI_RANGE]byte{}
+		}
+	case fpga_regs[LGW_FPGA_HISTO_RAM_ADDR].addr:
+		e.histo_ptr = 2 * int(data)
+	}
+}
+
+/* all the RSSI reads of a scan fall in the bin of the RSSI set for the scanned frequency */
+func (e *Emulator) histogram() {
+	freq := uint32((uint64(emu_get(fpga_regs[LGW_FPGA_HISTO_SCAN_FREQ], e.fpga_at)) * SX127X_XTAL_FREQ) >> 19)
+	rssi := -float64(e.sx127x[SX127X_REG_RSSIVALUE]) / 2
+	for f, r := range e.spectrum {
+		if is_equal_freq(f, freq) {
+			rssi = r
+		}
+	}
+	bin := int(-rssi * 2)
+	if bin < 0 {
+		bin = 0
+	} else if bin >= SPECTRAL_SCAN_RSSI_RANGE {
+		bin = SPECTRAL_SCAN_RSSI_RANGE - 1
+	}
+	nb := uint16(emu_get(fpga_regs[LGW_FPGA_HISTO_NB_READ], e.fpga_at))
+	e.histo = [2 * SPECTRAL_SCAN_RSSI_RANGE]byte{}
+	e.histo[2*bin] = byte(nb)
+	e.histo[2*bin+1] = byte(nb >> 8)
+	emu_set(fpga_regs[LGW_FPGA_STATUS], e.fpga_at, 1<<5) /* histogram done */
+}
+
+func (e *Emulator) fpga_read(addr byte) byte {
+	if addr == fpga_regs[LGW_FPGA_HISTO_RAM_DATA].addr && emu_get(fpga_regs[LGW_FPGA_CTRL_ACCESS_HISTO_MEM], e.fpga_at) == 1 {
+		b := e.histo[e.histo_ptr%len(e.histo)]
+		e.histo_ptr++
+		return b
+	}
+	r := fpga_regs[LGW_FPGA_LBT_TIMESTAMP_CH]
+	if (addr == r.addr || addr == r.addr+1) && emu_get(fpga_regs[LGW_FPGA_CTRL_FEATURE_START], e.fpga_at) == 1 {
+		/* last time the selected channel was free, 1 LSB = 256 us */
+		ch := emu_get(fpga_regs[LGW_FPGA_LBT_TIMESTAMP_SELECT_CH], e.fpga_at) % LBT_CHANNEL_FREQ_NB
+		cnt := e.counter()
+		if e.lbt_busy[ch] {
+			cnt = e.lbt_free[ch]
+		}
+		ts := (cnt & LBT_TIMESTAMP_MASK) / 256
+		return byte(ts >> (8 * uint(addr-r.addr)))
+	}
+	return e.fpga_mem[addr]
+}
+
+func (e *Emulator) counter() uint32 {
+	return uint32(time.Since(e.epoch) / time.Microsecond)
+}
+
+func (e *Emulator) sx1301_write(addr, data byte) {
+	pg := e.page()
+	p := e.mem_at(pg, addr)
+	old := *p
+	ro := *e.ro_at(pg, addr)
+	*p = (old & ro) | (data &^ ro)
+
+	switch {
+	case addr == PAGE_ADDR:
+		if data&0x80 != 0 {
+			e.reset()
+		}
+	case e.hit(LGW_TX_DATA_BUF_ADDR, pg, addr):
+		e.tx_ptr = int(data)
+		e.tx_len = 0
+	case e.hit(LGW_TX_DATA_BUF_DATA, pg, addr):
+		e.tx_buf[e.tx_ptr%EMU_TX_BUF_SIZE] = data
+		e.tx_ptr++
+		e.tx_len++
+	case e.hit(LGW_MCU_PROM_ADDR, pg, addr):
+		e.prom_ptr = int(data)
+	case e.hit(LGW_MCU_PROM_DATA, pg, addr):
+		e.prom[e.prom_ptr%EMU_PROM_SIZE] = data
+		e.prom_ptr++
+		if e.prom_ptr%EMU_PROM_SIZE == 0 {
+			e.prom_loaded()
+		}
+	case e.hit(LGW_RX_PACKET_DATA_FIFO_NUM_STORED, pg, addr):
+		if len(e.fifo) > 0 {
+			e.fifo = e.fifo[1:]
+		}
+		e.rx_ptr = 0
+	case e.hit(LGW_RADIO_SELECT, pg, addr):
+		e.agc_command(data)
+	case e.hit(LGW_SPI_RADIO_A__CS, pg, addr):
+		e.radio_spi(0, LGW_SPI_RADIO_A__CS, LGW_SPI_RADIO_A__ADDR, LGW_SPI_RADIO_A__DATA, LGW_SPI_RADIO_A__DATA_READBACK)
+	case e.hit(LGW_SPI_RADIO_B__CS, pg, addr):
+		e.radio_spi(1, LGW_SPI_RADIO_B__CS, LGW_SPI_RADIO_B__ADDR, LGW_SPI_RADIO_B__DATA, LGW_SPI_RADIO_B__DATA_READBACK)
+	case e.hit(LGW_TX_TRIG_ALL, pg, addr):
+		e.tx_trigger()
+	case e.hit(LGW_START_BIST0, pg, addr):
+		e.bist()
+	}
+	e.step()
+}
+
+/* the BIST engines finish at once, failing the RAMs marked by Set_bist_failure */
+func (e *Emulator) bist() {
+	for engine := 0; engine < 2; engine++ {
+		if e.get(bist_clear[engine]) == 1 {
+			e.set(bist_finished[engine], 0)
+			for _, id := range bist_status(engine) {
+				e.set(id, 0)
+			}
+		} else if (e.get(bist_start[engine]) == 1) && (e.get(bist_finished[engine]) == 0) {
+			for _, id := range bist_status(engine) {
+				if e.bist_fail[id] {
+					e.set(id, 1)
+				}
+			}
+			e.set(bist_finished[engine], 1)
+		}
+	}
+}
+
+func (e *Emulator) sx1301_read(addr byte, burst bool) byte {
+	pg := e.page()
+	switch {
+	case e.hit(LGW_RX_DATA_BUF_DATA, pg, addr):
+		if len(e.fifo) == 0 {
+			return 0
+		}
+		d := e.fifo[0].data
+		if e.rx_ptr >= len(d) {
+			return 0
+		}
+		e.rx_ptr++
+		return d[e.rx_ptr-1]
+	case e.hit(LGW_MCU_PROM_DATA, pg, addr):
+		b := e.prom[e.prom_ptr%EMU_PROM_SIZE]
+		if burst {
+			e.prom_ptr++ /* a single read only primes the read pipeline */
+		}
+		return b
+	case addr >= loregs[LGW_RX_PACKET_DATA_FIFO_NUM_STORED].addr && addr <= loregs[LGW_RX_PACKET_DATA_FIFO_PAYLOAD_SIZE].addr:
+		return e.fifo_byte(addr - loregs[LGW_RX_PACKET_DATA_FIFO_NUM_STORED].addr)
+	case e.hit(LGW_DBG_AGC_MCU_RAM_DATA, pg, addr):
+		return e.agc_ram[byte(e.get(LGW_DBG_AGC_MCU_RAM_ADDR))]
+	case e.hit(LGW_DBG_ARB_MCU_RAM_DATA, pg, addr):
+		return e.arb_ram[byte(e.get(LGW_DBG_ARB_MCU_RAM_ADDR))]
+	case pg == loregs[LGW_TIMESTAMP].page && addr >= loregs[LGW_TIMESTAMP].addr && addr < loregs[LGW_TIMESTAMP].addr+4:
+		cnt := e.counter()
+		if e.get(LGW_GPS_EN) == 1 {
+			cnt = e.trig_cnt
+		}
+		return byte(cnt >> (8 * uint(addr-loregs[LGW_TIMESTAMP].addr)))
+	}
+	return *e.mem_at(pg, addr)
+}
+
+/* RX FIFO registers: number of packets, start address (2 bytes), CRC status, payload size */
+func (e *Emulator) fifo_byte(i byte) byte {
+	if len(e.fifo) == 0 {
+		return 0
+	}
+	switch i {
+	case 0:
+		if len(e.fifo) > LGW_PKT_FIFO_SIZE {
+			return LGW_PKT_FIFO_SIZE
+		}
+		return byte(len(e.fifo))
+	case 3:
+		return e.fifo[0].status
+	case 4:
+		return byte(len(e.fifo[0].data) - RX_METADATA_NB)
+	}
+	return 0
+}
+
+/* identify the firmware that was just written in the program RAM and give it to its MCU */
+func (e *Emulator) prom_loaded() {
+	switch {
+	case bytes.Equal(e.prom[:], cal_firmware):
+		e.image[MCU_AGC] = emu_fw_cal
+	case bytes.Equal(e.prom[:], agc_firmware):
+		e.image[MCU_AGC] = emu_fw_agc
+	case bytes.Equal(e.prom[:], arb_firmware):
+		e.image[MCU_ARB] = emu_fw_arb
+	}
+}
+
+/* start or stop the MCUs depending on their reset bits */
+func (e *Emulator) step() {
+	if e.get(LGW_MCU_RST_0) == 1 {
+		e.running[MCU_ARB] = false
+	} else if !e.running[MCU_ARB] {
+		e.running[MCU_ARB] = true
+		e.arb_ram = [EMU_MCU_RAM_SIZE]byte{}
+		if e.image[MCU_ARB] == emu_fw_arb {
+			e.arb_ram[FW_VERSION_ADDR] = FW_VERSION_ARB
+		}
+	}
+
+	if e.get(LGW_MCU_RST_1) == 1 {
+		e.running[MCU_AGC] = false
+	} else if !e.running[MCU_AGC] {
+		e.running[MCU_AGC] = true
+		e.agc_ram = [EMU_MCU_RAM_SIZE]byte{}
+		e.set(LGW_MCU_AGC_STATUS, 0)
+		switch e.image[MCU_AGC] {
+		case emu_fw_cal:
+			e.agc_ram[FW_VERSION_ADDR] = FW_VERSION_CAL
+		case emu_fw_agc:
+			e.agc_ram[FW_VERSION_ADDR] = FW_VERSION_AGC
+			e.agc_wait = false
+			e.agc_phase = 0
+			e.agc_lut_idx = 0
+			e.set(LGW_MCU_AGC_STATUS, 0x10)
+			e.set(LGW_TX_STATUS, emu_tx_status_idle)
+		}
+	}
+
+	/* calibration starts as soon as the MCU can talk to the concentrator registers */
+	if e.running[MCU_AGC] && e.image[MCU_AGC] == emu_fw_cal && e.get(LGW_EMERGENCY_FORCE_HOST_CTRL) == 0 && e.get(LGW_MCU_AGC_STATUS) == 0 {
+		e.calibrate()
+	}
+}
+
+func (e *Emulator) calibrate() {
+	cmd := byte(e.get(LGW_RADIO_SELECT))
+	status := e.cal_status
+	if status == 0 {
+		status = 0x81
+		if cmd&0x01 != 0 {
+			status |= 0x02 | 0x08
+		}
+		if cmd&0x02 != 0 {
+			status |= 0x04 | 0x10
+		}
+		if cmd&0x04 != 0 {
+			status |= 0x20
+		}
+		if cmd&0x08 != 0 {
+			status |= 0x40
+		}
+	}
+	for i, v := range e.cal_offsets {
+		e.agc_ram[0xA0+i] = byte(v)
+	}
+	e.set(LGW_MCU_AGC_STATUS, int32(status))
+}
+
+/* AGC firmware init protocol: AGC_CMD_WAIT then a value, acknowledged in MCU_AGC_STATUS */
+func (e *Emulator) agc_command(data byte) {
+	if !e.running[MCU_AGC] || e.image[MCU_AGC] != emu_fw_agc {
+		return
+	}
+	if !e.agc_wait {
+		if data == AGC_CMD_WAIT {
+			e.agc_wait = true
+		}
+		return
+	}
+	e.agc_wait = false
+	switch e.agc_phase {
+	case 0: /* TX gain LUT */
+		if data == AGC_CMD_ABORT {
+			e.set(LGW_MCU_AGC_STATUS, 0x30)
+			e.agc_phase = 1
+			return
+		}
+		e.set(LGW_MCU_AGC_STATUS, int32(0x30+e.agc_lut_idx))
+		e.agc_lut_idx++
+		if e.agc_lut_idx == TX_GAIN_LUT_SIZE_MAX {
+			e.agc_phase = 1
+		}
+	case 1, 2: /* TX freq MSBs, chan_select option */
+		e.set(LGW_MCU_AGC_STATUS, int32(0x30+data))
+		e.agc_phase++
+	case 3: /* RADIO_SELECT */
+		e.set(LGW_MCU_AGC_STATUS, 0x40)
+		e.agc_phase++
+	}
+}
+
+/* SX127x mode changes are immediate: ModeReady, and RxReady in RX mode */
+func (e *Emulator) sx127x_write(addr, data byte) {
+	switch addr {
+	case SX127X_REG_VERSION, SX127X_REG_RSSIVALUE, SX127X_REG_IRQFLAGS1:
+		return /* read-only */
+	case SX127X_REG_OPMODE:
+		flags := byte(0x80)
+		if data&0x07 == SX127X_MODE_RX {
+			flags |= 0x40
+		}
+		e.sx127x[SX127X_REG_IRQFLAGS1] = flags
+	}
+	e.sx127x[addr] = data
+}
+
+/* SX125x SPI master: the transfer happens on the rising edge of the chip select */
+func (e *Emulator) radio_spi(rf_chain int, reg_cs, reg_add, reg_dat, reg_rb Lgw_reg_id) {
+	cs := e.get(reg_cs) == 1
+	rising := cs && !e.radio_cs[rf_chain]
+	e.radio_cs[rf_chain] = cs
+	if !rising {
+		return
+	}
+	a := byte(e.get(reg_add))
+	if a&0x80 != 0 {
+		if (a & 0x7F) != 0x07 {
+			e.radios[rf_chain][a&0x7F] = byte(e.get(reg_dat))
+		}
+	} else {
+		e.set(reg_rb, int32(e.radios[rf_chain][a&0x7F]))
+	}
+}
+
+/* LGW_TX_STATUS bits: 4 TX programmed, 5 and 6 TX sequence running, idle reads as the register default */
+const (
+	emu_tx_status_idle      = 0x80
+	emu_tx_status_scheduled = 0x10
+	emu_tx_status_emitting  = 0x30
+)
+
+func (e *Emulator) tx_trigger() {
+	switch {
+	case e.get(LGW_TX_TRIG_IMMEDIATE) == 1:
+		e.set(LGW_TX_STATUS, emu_tx_status_emitting)
+	case e.get(LGW_TX_TRIG_DELAYED) == 1 || e.get(LGW_TX_TRIG_GPS) == 1:
+		e.set(LGW_TX_STATUS, emu_tx_status_scheduled)
+	default:
+		e.set(LGW_TX_STATUS, emu_tx_status_idle)
+	}
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* test helpers */
+
+/* Inject_packet queues a raw packet in the RX FIFO, metadata layout is the one decoded by Lgw_receive */
+func (e *Emulator) Inject_packet(status byte, payload []byte, metadata []byte) error {
+	if len(metadata) != RX_METADATA_NB {
+		return fmt.Errorf("ERROR: METADATA MUST BE %d BYTES LONG\n", RX_METADATA_NB)
+	}
+	if len(payload) > 255 {
+		return fmt.Errorf("ERROR: PAYLOAD TOO BIG\n")
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	d := make([]byte, 0, len(payload)+RX_METADATA_NB)
+	d = append(d, payload...)
+	d = append(d, metadata...)
+	e.fifo = append(e.fifo, emu_pkt{status: status, data: d})
+	return nil
+}
+
+/* Inject_lora_packet queues a LoRa packet received on if_chain, sf is 7 to 12, cr is one of CR_LORA_* */
+func (e *Emulator) Inject_lora_packet(if_chain, sf, cr byte, crc_ok bool, rssi byte, snr float64, count_us uint32, payload []byte) error {
+	m := make([]byte, RX_METADATA_NB)
+	m[0] = if_chain
+	m[1] = (sf << 4) | (cr << 1)
+	m[2] = byte(int8(snr * 4))
+	m[3] = m[2]
+	m[4] = m[2]
+	m[5] = rssi
+	m[6] = byte(count_us)
+	m[7] = byte(count_us >> 8)
+	m[8] = byte(count_us >> 16)
+	m[9] = byte(count_us >> 24)
+	status := byte(5)
+	if !crc_ok {
+		status = 7
+	}
+	return e.Inject_packet(status, payload, m)
+}
+
+/* Tx_buffer returns what was written in the TX data buffer since TX_DATA_BUF_ADDR was last set */
+func (e *Emulator) Tx_buffer() []byte {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	n := e.tx_len
+	if n > EMU_TX_BUF_SIZE {
+		n = EMU_TX_BUF_SIZE
+	}
+	b := make([]byte, n)
+	start := e.tx_ptr - e.tx_len
+	for i := range b {
+		b[i] = e.tx_buf[(start+i)%EMU_TX_BUF_SIZE]
+	}
+	return b
+}
+
+/* Reg returns the value of an SX1301 register as held by the emulator */
+func (e *Emulator) Reg(register_id Lgw_reg_id) int32 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.get(register_id)
+}
+
+/* Fpga_reg returns the value of an FPGA register as held by the emulator */
+func (e *Emulator) Fpga_reg(register_id Lgw_fpga_reg_id) int32 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return emu_get(fpga_regs[register_id], e.fpga_at)
+}
+
+/* Set_calibration forces the calibration status and the TX DC offsets returned by the calibration firmware */
+func (e *Emulator) Set_calibration(status byte, offsets [32]int8) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.cal_status = status
+	e.cal_offsets = offsets
+}
+
+/* Set_sx127x sets the version register of the SX127x (SX1272_VERSION or SX1276_VERSION) */
+func (e *Emulator) Set_sx127x(version byte) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.sx127x[SX127X_REG_VERSION] = version
+}
+
+/* Set_sx127x_rssi sets the RSSI seen by the SX127x, in dBm */
+func (e *Emulator) Set_sx127x_rssi(rssi float64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.sx127x[SX127X_REG_RSSIVALUE] = byte(-rssi * 2)
+}
+
+/* Set_spectrum sets the RSSI the spectral scan measures around freq_hz, other frequencies see the SX127x RSSI */
+func (e *Emulator) Set_spectrum(freq_hz uint32, rssi float64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.spectrum == nil {
+		e.spectrum = make(map[uint32]float64)
+	}
+	e.spectrum[freq_hz] = rssi
+}
+
+/* Set_bist_failure makes the BIST of a RAM fail or pass, register_id is its *_BIST_STATUS register */
+func (e *Emulator) Set_bist_failure(register_id Lgw_reg_id, fail bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.bist_fail == nil {
+		e.bist_fail = make(map[Lgw_reg_id]bool)
+	}
+	e.bist_fail[register_id] = fail
+}
+
+/* Pps latches the counter as a PPS edge would */
+func (e *Emulator) Pps() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.trig_cnt = e.counter()
+}
+
+/* Set_lbt_busy marks an LBT channel busy or free, a busy channel keeps reporting when it was last free */
+func (e *Emulator) Set_lbt_busy(channel int, busy bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if busy && !e.lbt_busy[channel] {
+		e.lbt_free[channel] = e.counter()
+	}
+	e.lbt_busy[channel] = busy
+}
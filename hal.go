@@ -1,11 +1,11 @@
 package liblorago
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math"
-	"os"
 	"reflect"
 	"time"
 )
@@ -163,9 +163,23 @@ func SET_PPM_ON(bw, dr byte) bool {
 }
 func IF_HZ_TO_REG(f int32) int32 { return (f << 5) / 15625 }
 
-func Load_firmware(c *os.File, target int, spi_mux_mode, spi_mux_target byte, firmware []byte) error {
-	var reg_rst uint16
-	var reg_sel uint16
+func IS_LORA_BW(bw byte) bool {
+	return ((bw == BW_125KHZ) || (bw == BW_250KHZ) || (bw == BW_500KHZ))
+}
+func IS_LORA_STD_DR(dr uint32) bool {
+	return ((dr == DR_LORA_SF7) || (dr == DR_LORA_SF8) || (dr == DR_LORA_SF9) || (dr == DR_LORA_SF10) || (dr == DR_LORA_SF11) || (dr == DR_LORA_SF12))
+}
+func IS_LORA_CR(cr byte) bool {
+	return ((cr == CR_LORA_4_5) || (cr == CR_LORA_4_6) || (cr == CR_LORA_4_7) || (cr == CR_LORA_4_8))
+}
+func IS_FSK_DR(dr uint32) bool { return ((dr >= DR_FSK_MIN) && (dr <= DR_FSK_MAX)) }
+func IS_TX_MODE(mode byte) bool {
+	return ((mode == IMMEDIATE) || (mode == TIMESTAMPED) || (mode == ON_GPS))
+}
+
+func Load_firmware(c *Concentrator, target int, firmware []byte) error {
+	var reg_rst Lgw_reg_id
+	var reg_sel Lgw_reg_id
 
 	if target == MCU_ARB {
 		reg_rst = LGW_MCU_RST_0
@@ -178,33 +192,33 @@ func Load_firmware(c *os.File, target int, spi_mux_mode, spi_mux_target byte, fi
 	}
 
 	/* reset the targeted MCU */
-	err := Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_rst, 1)
+	err := Lgw_reg_w(c, reg_rst, 1)
 	if err != nil {
 		return err
 	}
 
 	/* set mux to access MCU program RAM and set address to 0 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_sel, 0)
+	err = Lgw_reg_w(c, reg_sel, 0)
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_MCU_PROM_ADDR, 0)
+	err = Lgw_reg_w(c, LGW_MCU_PROM_ADDR, 0)
 	if err != nil {
 		return err
 	}
 
 	/* write the program in one burst */
-	err = Lgw_reg_wb(c, spi_mux_mode, spi_mux_target, LGW_MCU_PROM_DATA, firmware)
+	err = Lgw_reg_wb(c, LGW_MCU_PROM_DATA, firmware)
 	if err != nil {
 		return err
 	}
 	/* Read back firmware code for check */
-	_, err = Lgw_reg_r(c, spi_mux_mode, spi_mux_target, LGW_MCU_PROM_DATA) /* bug workaround */
+	_, err = Lgw_reg_r(c, LGW_MCU_PROM_DATA) /* bug workaround */
 	if err != nil {
 		return err
 	}
 
-	fw_check, err := Lgw_reg_rb(c, spi_mux_mode, spi_mux_target, LGW_MCU_PROM_DATA, uint16(len(firmware)))
+	fw_check, err := Lgw_reg_rb(c, LGW_MCU_PROM_DATA, uint16(len(firmware)))
 	if err != nil {
 		return err
 	}
@@ -214,7 +228,7 @@ func Load_firmware(c *os.File, target int, spi_mux_mode, spi_mux_target byte, fi
 	}
 
 	/* give back control of the MCU program ram to the MCU */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_sel, 1)
+	err = Lgw_reg_w(c, reg_sel, 1)
 	if err != nil {
 		return err
 	}
@@ -246,14 +260,15 @@ type State struct {
 
 	lorawan_public bool
 	rf_clkout      byte
-
-	/* TX I/Q imbalance coefficients for mixer gain = 8 to 15 */
-	cal_offset_a_i [8]int8 /* TX I offset for radio A */
-	cal_offset_a_q [8]int8 /* TX Q offset for radio A */
-	cal_offset_b_i [8]int8 /* TX I offset for radio B */
-	cal_offset_b_q [8]int8 /* TX Q offset for radio B */
+	cal_timeout    time.Duration /* how long to wait for the end of the calibration, CAL_TIMEOUT if 0 */
 
 	txgain_lut lgw_tx_gain_lut_s
+
+	lbt_enable            bool
+	lbt_rssi_target       int8 /* RSSI threshold to detect if channel is busy or not (dBm) */
+	lbt_rssi_offset       int8 /* RSSI offset to be applied to SX127x RSSI values */
+	lbt_nb_active_channel uint8
+	lbt_channel_cfg       [LBT_CHANNEL_FREQ_NB]lgw_conf_lbt_chan_s
 }
 
 /**
@@ -279,21 +294,24 @@ type lgw_tx_gain_lut_s struct {
 
 type Config struct {
 	SX1301Conf struct {
-		LorawanPublic bool `json:"lorawan_public"`
-		Clksrc        byte `json:"clksrc"`
-		Radio0        struct {
-			Enable     bool    `json:"enable"`
-			Type       string  `json:"type"`
-			Freq       uint32  `json:"freq"`
-			RssiOffset float64 `json:"rssi_offset"`
-			TxEnable   bool    `json:"tx_enable"`
+		LorawanPublic      bool   `json:"lorawan_public"`
+		Clksrc             byte   `json:"clksrc"`
+		CalibrationTimeout uint32 `json:"calibration_timeout_ms"`
+		Radio0             struct {
+			Enable      bool    `json:"enable"`
+			Type        string  `json:"type"`
+			Freq        uint32  `json:"freq"`
+			RssiOffset  float64 `json:"rssi_offset"`
+			TxEnable    bool    `json:"tx_enable"`
+			TxNotchFreq uint32  `json:"tx_notch_freq"`
 		} `json:"radio_0"`
 		Radio1 struct {
-			Enable     bool    `json:"enable"`
-			Type       string  `json:"type"`
-			Freq       uint32  `json:"freq"`
-			RssiOffset float64 `json:"rssi_offset"`
-			TxEnable   bool    `json:"tx_enable"`
+			Enable      bool    `json:"enable"`
+			Type        string  `json:"type"`
+			Freq        uint32  `json:"freq"`
+			RssiOffset  float64 `json:"rssi_offset"`
+			TxEnable    bool    `json:"tx_enable"`
+			TxNotchFreq uint32  `json:"tx_notch_freq"`
 		} `json:"radio_1"`
 		ChanMultiSF0 struct {
 			Enable bool  `json:"enable"`
@@ -349,6 +367,15 @@ type Config struct {
 			Bandwidth int    `json:"bandwidth"`
 			Datarate  uint32 `json:"datarate"`
 		} `json:"chan_FSK"`
+		LbtCfg struct {
+			Enable           bool `json:"enable"`
+			RssiTarget       int8 `json:"rssi_target"`
+			Sx127xRssiOffset int8 `json:"sx127x_rssi_offset"`
+			ChanCfg          []struct {
+				FreqHz     uint32 `json:"freq_hz"`
+				ScanTimeUs uint16 `json:"scan_time_us"`
+			} `json:"chan_cfg"`
+		} `json:"lbt_cfg"`
 	} `json:"SX1301_conf"`
 	GatewayConf struct {
 		GatewayID string `json:"gateway_ID"`
@@ -396,10 +423,12 @@ func ParseConfig(configpath string) (*State, error) {
 	}
 	state.lorawan_public = config.SX1301Conf.LorawanPublic
 	state.rf_clkout = config.SX1301Conf.Clksrc
+	state.cal_timeout = time.Duration(config.SX1301Conf.CalibrationTimeout) * time.Millisecond
 	state.rf_enable[0] = config.SX1301Conf.Radio0.Enable
 	state.rf_rx_freq[0] = config.SX1301Conf.Radio0.Freq
 	state.rf_rssi_offset[0] = config.SX1301Conf.Radio0.RssiOffset
 	state.rf_tx_enable[0] = config.SX1301Conf.Radio0.TxEnable
+	state.rf_tx_notch_freq[0] = config.SX1301Conf.Radio0.TxNotchFreq
 	switch config.SX1301Conf.Radio0.Type {
 	case "SX1257":
 		state.rf_radio_type[0] = LGW_RADIO_TYPE_SX1257
@@ -412,6 +441,7 @@ func ParseConfig(configpath string) (*State, error) {
 	state.rf_rx_freq[1] = config.SX1301Conf.Radio1.Freq
 	state.rf_rssi_offset[1] = config.SX1301Conf.Radio1.RssiOffset
 	state.rf_tx_enable[1] = config.SX1301Conf.Radio1.TxEnable
+	state.rf_tx_notch_freq[1] = config.SX1301Conf.Radio1.TxNotchFreq
 	switch config.SX1301Conf.Radio1.Type {
 	case "SX1257":
 		state.rf_radio_type[1] = LGW_RADIO_TYPE_SX1257
@@ -510,102 +540,205 @@ func ParseConfig(configpath string) (*State, error) {
 	state.fsk_rx_dr = config.SX1301Conf.ChanFSK.Datarate
 	state.fsk_sync_word_size = 3
 	state.fsk_sync_word = 0xC194C1
+
+	state.lbt_enable = config.SX1301Conf.LbtCfg.Enable
+	if state.lbt_enable {
+		if len(config.SX1301Conf.LbtCfg.ChanCfg) < 1 || len(config.SX1301Conf.LbtCfg.ChanCfg) > LBT_CHANNEL_FREQ_NB {
+			return nil, fmt.Errorf("ERROR: NUMBER OF LBT CHANNELS MUST BE 1 TO %d\n", LBT_CHANNEL_FREQ_NB)
+		}
+		state.lbt_rssi_target = config.SX1301Conf.LbtCfg.RssiTarget
+		state.lbt_rssi_offset = config.SX1301Conf.LbtCfg.Sx127xRssiOffset
+		state.lbt_nb_active_channel = uint8(len(config.SX1301Conf.LbtCfg.ChanCfg))
+		for i, ch := range config.SX1301Conf.LbtCfg.ChanCfg {
+			state.lbt_channel_cfg[i].freq_hz = ch.FreqHz
+			state.lbt_channel_cfg[i].scan_time_us = ch.ScanTimeUs
+			if ch.ScanTimeUs == 0 {
+				state.lbt_channel_cfg[i].scan_time_us = LBT_SCAN_TIME_FAST
+			}
+			if (state.lbt_channel_cfg[i].scan_time_us != LBT_SCAN_TIME_FAST) && (state.lbt_channel_cfg[i].scan_time_us != LBT_SCAN_TIME_SLOW) {
+				return nil, fmt.Errorf("ERROR: LBT CHANNEL %d SCAN TIME MUST BE %d OR %d\n", i, LBT_SCAN_TIME_FAST, LBT_SCAN_TIME_SLOW)
+			}
+		}
+	}
 	return &state, nil
 }
 
-func Lgw_start(path string, s *State) (*os.File, byte, byte, error) {
+/* Start_stage is a step of the startup, as reported by Lgw_start_ctx */
+type Start_stage int
+
+const (
+	START_CONNECTED            Start_stage = iota /* SPI link open and FPGA configured */
+	START_RADIOS_SET_UP                           /* radios powered, reset and their PLL locked */
+	START_CALIBRATION_STARTED                     /* calibration firmware running, about 2.2 s */
+	START_CALIBRATION_FINISHED                    /* the event carries the calibration result, also sent on timeout */
+	START_FIRMWARE_LOADED                         /* arbiter and AGC firmwares loaded */
+	START_AGC_INITIALISED                         /* TX gain LUT loaded and AGC running */
+)
+
+var start_stage_names = []string{"connected", "radios set up", "calibration started", "calibration finished", "firmware loaded", "AGC initialised"}
+
+func (st Start_stage) String() string {
+	if (st < 0) || (int(st) >= len(start_stage_names)) {
+		return fmt.Sprintf("Start_stage(%d)", int(st))
+	}
+	return start_stage_names[st]
+}
+
+/* Start_event is one step of the startup */
+type Start_event struct {
+	Stage       Start_stage
+	Elapsed     time.Duration      /* since the start began */
+	Calibration *CalibrationResult /* START_CALIBRATION_FINISHED only */
+}
+
+func Lgw_start(c *Concentrator) error {
+	return Lgw_start_ctx(context.Background(), c, nil)
+}
+
+/*
+Lgw_start_ctx is Lgw_start giving up as soon as ctx is done, with the error of ctx. progress, if not nil,
+is called at each step of the startup; it runs with the concentrator held and must not call its methods.
+A cancelled start closes the SPI link but doesn't power anything down: the radios, the SX127x and the
+MCUs stay as the cancellation found them until the board is started again, which resets them.
+*/
+func Lgw_start_ctx(ctx context.Context, c *Concentrator, progress func(Start_event)) (err error) {
+	begin := time.Now()
+	emit := func(stage Start_stage, cal *CalibrationResult) {
+		if progress != nil {
+			progress(Start_event{Stage: stage, Elapsed: time.Since(begin), Calibration: cal})
+		}
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if (err != nil) && (ctx.Err() != nil) {
+			Lgw_disconnect(c)
+		}
+	}()
+	c.calibration = nil
+	s := c.state
 	e := s.rf_tx_enable[1]
 	index := 0
 	if e {
 		index = 1
 	}
-	f, lgw_spi_mux_mode, spi_mux_target, err := Lgw_connect(path, false, s.rf_tx_notch_freq[index])
+	err = Lgw_connect(c, false, s.rf_tx_notch_freq[index])
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: FAIL TO CONNECT BOARD\n")
+		return fmt.Errorf("ERROR: FAIL TO CONNECT BOARD\n")
+	}
+	emit(START_CONNECTED, nil)
+
+	/* per-board calibration from the EEPROM takes precedence over the configuration */
+	c.board = nil
+	if c.spi_mux_mode == LGW_SPI_MUX_MODE1 {
+		b, err := Lgw_board_identity(c)
+		if err != nil {
+			fmt.Printf("WARNING: %s", err)
+		} else if b != nil {
+			st := *c.state
+			b.apply(&st)
+			c.state = &st
+			s = c.state
+			c.board = b
+		}
 	}
 
 	/* reset the registers (also shuts the radios down) */
-	err = Lgw_soft_reset(f, lgw_spi_mux_mode)
+	err = Lgw_soft_reset(c)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* gate clocks */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_GLOBAL_EN, 0)
+	err = Lgw_reg_w(c, LGW_GLOBAL_EN, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CLK32M_EN, 0)
+	err = Lgw_reg_w(c, LGW_CLK32M_EN, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* switch on and reset the radios (also starts the 32 MHz XTAL) */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_A_EN, 1)
+	err = Lgw_reg_w(c, LGW_RADIO_A_EN, 1)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_RADIO_B_EN, 1)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_B_EN, 1)
+	err = sleep_ctx(ctx, 500*time.Millisecond) /* TODO: optimize */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(500 * time.Millisecond) /* TODO: optimize */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_RST, 1)
+	err = Lgw_reg_w(c, LGW_RADIO_RST, 1)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
+	}
+	err = sleep_ctx(ctx, 5*time.Millisecond)
+	if err != nil {
+		return err
 	}
-	time.Sleep(5 * time.Millisecond)
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_RST, 0)
+	err = Lgw_reg_w(c, LGW_RADIO_RST, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* setup the radios */
-	err = Lgw_setup_sx125x(f, lgw_spi_mux_mode, spi_mux_target, 0, s.rf_clkout, s.rf_enable[0], s.rf_radio_type[0], s.rf_rx_freq[0])
+	err = Lgw_setup_sx125x(c, 0, s.rf_clkout, s.rf_enable[0], s.rf_radio_type[0], s.rf_rx_freq[0])
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: Failed to setup sx125x radio for RF chain 0\n")
+		return fmt.Errorf("ERROR: Failed to setup sx125x radio for RF chain 0\n")
 	}
-	err = Lgw_setup_sx125x(f, lgw_spi_mux_mode, spi_mux_target, 1, s.rf_clkout, s.rf_enable[1], s.rf_radio_type[1], s.rf_rx_freq[1])
+	err = Lgw_setup_sx125x(c, 1, s.rf_clkout, s.rf_enable[1], s.rf_radio_type[1], s.rf_rx_freq[1])
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: Failed to setup sx125x radio for RF chain 1\n")
+		return fmt.Errorf("ERROR: Failed to setup sx125x radio for RF chain 1\n")
 	}
+	emit(START_RADIOS_SET_UP, nil)
 
 	/* gives AGC control of GPIOs to enable Tx external digital filter */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_GPIO_MODE, 31) /* Set all GPIOs as output */
+	err = Lgw_reg_w(c, LGW_GPIO_MODE, 31) /* Set all GPIOs as output */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_GPIO_SELECT_OUTPUT, 2)
+	err = Lgw_reg_w(c, LGW_GPIO_SELECT_OUTPUT, 2)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
-	//  /* Configure LBT */
-	//  if (lbt_is_enabled() == true) {
-	//      Lgw_reg_w(LGW_CLK32M_EN, 1);
-	//      i = lbt_setup();
-	//      if (i != LGW_LBT_SUCCESS) {
-	//          DEBUG_MSG("ERROR: lbt_setup() did not return SUCCESS\n");
-	//          return LGW_HAL_ERROR;
-	//      }
+	/* Configure LBT */
+	if s.lbt_enable {
+		err = Lgw_reg_w(c, LGW_CLK32M_EN, 1)
+		if err != nil {
+			return err
+		}
+		err = lbt_setup(ctx, c)
+		if err != nil {
+			return err
+		}
 
-	//      /* Start SX1301 counter and LBT FSM at the same time to be in sync */
-	//      Lgw_reg_w(LGW_CLK32M_EN, 0);
-	//      i = lbt_start();
-	//      if (i != LGW_LBT_SUCCESS) {
-	//          DEBUG_MSG("ERROR: lbt_start() did not return SUCCESS\n");
-	//          return LGW_HAL_ERROR;
-	//      }
-	//  }
+		/* Start SX1301 counter and LBT FSM at the same time to be in sync */
+		err = Lgw_reg_w(c, LGW_CLK32M_EN, 0)
+		if err != nil {
+			return err
+		}
+		err = lbt_start(c)
+		if err != nil {
+			return err
+		}
+	}
 
 	/* Enable clocks */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_GLOBAL_EN, 1)
+	err = Lgw_reg_w(c, LGW_GLOBAL_EN, 1)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CLK32M_EN, 1)
+	err = Lgw_reg_w(c, LGW_CLK32M_EN, 1)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* GPIOs table :
@@ -638,169 +771,138 @@ func Lgw_start(path string, s *State) (*os.File, byte, byte, error) {
 	case LGW_RADIO_TYPE_SX1257:
 		cal_cmd |= 0x00 /* Bit 5: 0: SX1257, 1: SX1255 */
 	default:
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: UNEXPECTED VALUE %d FOR RADIO TYPE\n", s.rf_radio_type[0])
+		return fmt.Errorf("ERROR: UNEXPECTED VALUE %d FOR RADIO TYPE\n", s.rf_radio_type[0])
 	}
 
-	cal_cmd |= 0x00  /* Bit 6-7: Board type 0: ref, 1: FPGA, 3: board X */
-	cal_time := 2300 /* measured between 2.1 and 2.2 sec, because 1 TX only */
+	cal_cmd |= 0x00 /* Bit 6-7: Board type 0: ref, 1: FPGA, 3: board X */
 
 	/* Load the calibration firmware  */
-	err = Load_firmware(f, MCU_AGC, lgw_spi_mux_mode, spi_mux_target, cal_firmware)
+	err = Load_firmware(c, MCU_AGC, cal_firmware)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FORCE_HOST_RADIO_CTRL, 0)
+	err = Lgw_reg_w(c, LGW_FORCE_HOST_RADIO_CTRL, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	} /* gives to AGC MCU the control of the radios */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, int32(cal_cmd)) /* send calibration configuration word */
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, int32(cal_cmd)) /* send calibration configuration word */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_RST_1, 0)
+	err = Lgw_reg_w(c, LGW_MCU_RST_1, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* Check firmware version */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, FW_VERSION_ADDR)
+	err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, FW_VERSION_ADDR)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	read_val, err := Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+	read_val, err := Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	fw_version := uint8(read_val)
 	if fw_version != FW_VERSION_CAL {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: Version of calibration firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_CAL)
+		return fmt.Errorf("ERROR: Version of calibration firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_CAL)
 	}
 
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_PAGE_REG, 3) /* Calibration will start on this condition as soon as MCU can talk to concentrator registers */
+	err = Lgw_reg_w(c, LGW_PAGE_REG, 3) /* Calibration will start on this condition as soon as MCU can talk to concentrator registers */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_EMERGENCY_FORCE_HOST_CTRL, 0) /* Give control of concentrator registers to MCU */
+	err = Lgw_reg_w(c, LGW_EMERGENCY_FORCE_HOST_CTRL, 0) /* Give control of concentrator registers to MCU */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* Wait for calibration to end */
-	fmt.Printf("Note: calibration started (time: %u ms)\n", cal_time)
-	time.Sleep(time.Duration(cal_time) * time.Millisecond)                                 /* Wait for end of calibration */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_EMERGENCY_FORCE_HOST_CTRL, 1) /* Take back control */
-	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+	emit(START_CALIBRATION_STARTED, nil)
+	cal, err := lgw_calibration_wait(ctx, c, s.cal_timeout)
+	if cal != nil {
+		c.calibration = cal
+		emit(START_CALIBRATION_FINISHED, cal)
 	}
-
-	/* Get calibration status */
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
-	}
-	cal_status := uint8(read_val)
-	/*
-	   bit 7: calibration finished
-	   bit 0: could access SX1301 registers
-	   bit 1: could access radio A registers
-	   bit 2: could access radio B registers
-	   bit 3: radio A RX image rejection successful
-	   bit 4: radio B RX image rejection successful
-	   bit 5: radio A TX DC Offset correction successful
-	   bit 6: radio B TX DC Offset correction successful
-	*/
-	if (cal_status & 0x81) != 0x81 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: CALIBRATION FAILURE (STATUS = %d)\n", cal_status)
-	} else {
-		fmt.Printf("Note: calibration finished (status = %d)\n", cal_status)
-	}
-	if s.rf_enable[0] && ((cal_status & 0x02) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: calibration could not access radio A\n")
-	}
-	if s.rf_enable[1] && ((cal_status & 0x04) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: calibration could not access radio B\n")
-	}
-	if s.rf_enable[0] && ((cal_status & 0x08) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: problem in calibration of radio A for image rejection\n")
-	}
-	if s.rf_enable[1] && ((cal_status & 0x10) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: problem in calibration of radio B for image rejection\n")
-	}
-	if s.rf_enable[0] && s.rf_tx_enable[0] && ((cal_status & 0x20) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: problem in calibration of radio A for TX DC offset\n")
+		return err
 	}
-	if s.rf_enable[1] && s.rf_tx_enable[1] && ((cal_status & 0x40) == 0) {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("WARNING: problem in calibration of radio B for TX DC offset\n")
+	err = cal.check(s)
+	if err != nil {
+		return err
 	}
 
 	/* Get TX DC offset values */
 	for i := 0; i <= 7; i++ {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xA0+i))
+		err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xA0+i))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+		read_val, err = Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		s.cal_offset_a_i[i] = int8(read_val)
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xA8+i))
+		c.cal_offset_a_i[i] = int8(read_val)
+		err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xA8+i))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+		read_val, err = Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		s.cal_offset_a_q[i] = int8(read_val)
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xB0+i))
+		c.cal_offset_a_q[i] = int8(read_val)
+		err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xB0+i))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+		read_val, err = Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		s.cal_offset_b_i[i] = int8(read_val)
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xB8+i))
+		c.cal_offset_b_i[i] = int8(read_val)
+		err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, int32(0xB8+i))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+		read_val, err = Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		s.cal_offset_b_q[i] = int8(read_val)
+		c.cal_offset_b_q[i] = int8(read_val)
 	}
 
 	/* load adjusted parameters */
-	err = Lgw_constant_adjust(f, lgw_spi_mux_mode, spi_mux_target, s)
+	err = Lgw_constant_adjust(c)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* Sanity check for RX frequency */
 	if s.rf_rx_freq[0] == 0 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: wrong configuration, rf_rx_freq[0] is not set\n")
+		return fmt.Errorf("ERROR: wrong configuration, rf_rx_freq[0] is not set\n")
 	}
 
+	/* the modems are configured in one go: plain register settings, the calibration is over and the host has the registers back */
+	batch := NewRegBatch(c)
+
 	/* Freq-to-time-drift calculation */
 	x := 4096000000 / (s.rf_rx_freq[0] >> 1) /* dividend: (4*2048*1000000) >> 1, rescaled to avoid 32b overflow */
 	if x > 63 {
 		x = 63 /* saturation */
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FREQ_TO_TIME_DRIFT, int32(x)) /* default 9 */
+	err = batch.Reg_w(LGW_FREQ_TO_TIME_DRIFT, int32(x)) /* default 9 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	x = 4096000000 / (s.rf_rx_freq[0] >> 3) /* dividend: (16*2048*1000000) >> 3, rescaled to avoid 32b overflow */
 	if x > 63 {
 		x = 63 /* saturation */
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_FREQ_TO_TIME_DRIFT, int32(x)) /* default 36 */
+	err = batch.Reg_w(LGW_MBWSSF_FREQ_TO_TIME_DRIFT, int32(x)) /* default 36 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* configure LoRa 'multi' demodulators aka. LoRa 'sensor' channels (IF0-3) */
@@ -817,426 +919,547 @@ func Lgw_start(path string, s *State) (*os.File, byte, byte, error) {
 	   will be loaded in LGW_RADIO_SELECT at the end of start procedure.
 	*/
 
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_0, IF_HZ_TO_REG(s.if_freq[0])) /* default -384 */
+	err = batch.Reg_w(LGW_IF_FREQ_0, IF_HZ_TO_REG(s.if_freq[0])) /* default -384 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_1, IF_HZ_TO_REG(s.if_freq[1])) /* default -128 */
+	err = batch.Reg_w(LGW_IF_FREQ_1, IF_HZ_TO_REG(s.if_freq[1])) /* default -128 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_2, IF_HZ_TO_REG(s.if_freq[2])) /* default 128 */
+	err = batch.Reg_w(LGW_IF_FREQ_2, IF_HZ_TO_REG(s.if_freq[2])) /* default 128 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_3, IF_HZ_TO_REG(s.if_freq[3])) /* default 384 */
+	err = batch.Reg_w(LGW_IF_FREQ_3, IF_HZ_TO_REG(s.if_freq[3])) /* default 384 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_4, IF_HZ_TO_REG(s.if_freq[4])) /* default -384 */
+	err = batch.Reg_w(LGW_IF_FREQ_4, IF_HZ_TO_REG(s.if_freq[4])) /* default -384 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_5, IF_HZ_TO_REG(s.if_freq[5])) /* default -128 */
+	err = batch.Reg_w(LGW_IF_FREQ_5, IF_HZ_TO_REG(s.if_freq[5])) /* default -128 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_6, IF_HZ_TO_REG(s.if_freq[6])) /* default 128 */
+	err = batch.Reg_w(LGW_IF_FREQ_6, IF_HZ_TO_REG(s.if_freq[6])) /* default 128 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_7, IF_HZ_TO_REG(s.if_freq[7])) /* default 384 */
+	err = batch.Reg_w(LGW_IF_FREQ_7, IF_HZ_TO_REG(s.if_freq[7])) /* default 384 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	var corr int32
 	if s.if_enable[0] {
 		corr = int32(s.lora_multi_sfmask[0])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR0_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR0_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[1] {
 		corr = int32(s.lora_multi_sfmask[1])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR1_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR1_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[2] {
 		corr = int32(s.lora_multi_sfmask[2])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR2_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR2_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[3] {
 		corr = int32(s.lora_multi_sfmask[3])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR3_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR3_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[4] {
 		corr = int32(s.lora_multi_sfmask[4])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR4_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR4_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[5] {
 		corr = int32(s.lora_multi_sfmask[5])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR5_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR5_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[6] {
 		corr = int32(s.lora_multi_sfmask[6])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR6_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR6_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[7] {
 		corr = int32(s.lora_multi_sfmask[7])
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CORR7_DETECT_EN, corr) /* default 0 */
+	err = batch.Reg_w(LGW_CORR7_DETECT_EN, corr) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_PPM_OFFSET, 0x60) /* as the threshold is 16ms, use 0x60 to enable ppm_offset for SF12 and SF11 @125kHz*/
+	err = batch.Reg_w(LGW_PPM_OFFSET, 0x60) /* as the threshold is 16ms, use 0x60 to enable ppm_offset for SF12 and SF11 @125kHz*/
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_CONCENTRATOR_MODEM_ENABLE, 1) /* default 0 */
+	err = batch.Reg_w(LGW_CONCENTRATOR_MODEM_ENABLE, 1) /* default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* configure LoRa 'stand-alone' modem (IF8) */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_8, IF_HZ_TO_REG(s.if_freq[8])) /* MBWSSF modem (default 0) */
+	err = batch.Reg_w(LGW_IF_FREQ_8, IF_HZ_TO_REG(s.if_freq[8])) /* MBWSSF modem (default 0) */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[8] == true {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RADIO_SELECT, int32(s.if_rf_chain[8]))
+		err = batch.Reg_w(LGW_MBWSSF_RADIO_SELECT, int32(s.if_rf_chain[8]))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 		switch s.lora_rx_bw {
 		case BW_125KHZ:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_MODEM_BW, 0)
+			err = batch.Reg_w(LGW_MBWSSF_MODEM_BW, 0)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case BW_250KHZ:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_MODEM_BW, 1)
+			err = batch.Reg_w(LGW_MBWSSF_MODEM_BW, 1)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case BW_500KHZ:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_MODEM_BW, 2)
+			err = batch.Reg_w(LGW_MBWSSF_MODEM_BW, 2)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		default:
-			return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", s.lora_rx_bw)
+			return fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", s.lora_rx_bw)
 		}
 		switch s.lora_rx_sf {
 		case DR_LORA_SF7:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 7)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 7)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case DR_LORA_SF8:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 8)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 8)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case DR_LORA_SF9:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 9)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 9)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case DR_LORA_SF10:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 10)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 10)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case DR_LORA_SF11:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 11)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 11)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		case DR_LORA_SF12:
-			err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_RATE_SF, 12)
+			err = batch.Reg_w(LGW_MBWSSF_RATE_SF, 12)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, spi_mux_target, err
+				return err
 			}
 		default:
-			return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", s.lora_rx_sf)
+			return fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", s.lora_rx_sf)
 		}
 		var offset int32
 		if s.lora_rx_ppm_offset {
 			offset = 1
 		}
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_PPM_OFFSET, offset) /* default 0 */
+		err = batch.Reg_w(LGW_MBWSSF_PPM_OFFSET, offset) /* default 0 */
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_MODEM_ENABLE, 1) /* default 0 */
+		err = batch.Reg_w(LGW_MBWSSF_MODEM_ENABLE, 1) /* default 0 */
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 	} else {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MBWSSF_MODEM_ENABLE, 0)
+		err = batch.Reg_w(LGW_MBWSSF_MODEM_ENABLE, 0)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 	}
 
 	/* configure FSK modem (IF9) */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_IF_FREQ_9, IF_HZ_TO_REG(s.if_freq[9])) /* FSK modem, default 0 */
+	err = batch.Reg_w(LGW_IF_FREQ_9, IF_HZ_TO_REG(s.if_freq[9])) /* FSK modem, default 0 */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_PSIZE, int32(s.fsk_sync_word_size-1))
+	err = batch.Reg_w(LGW_FSK_PSIZE, int32(s.fsk_sync_word_size-1))
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_TX_PSIZE, int32(s.fsk_sync_word_size-1))
+	err = batch.Reg_w(LGW_FSK_TX_PSIZE, int32(s.fsk_sync_word_size-1))
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	fsk_sync_word_reg := s.fsk_sync_word << (8 * (8 - s.fsk_sync_word_size))
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_REF_PATTERN_LSB, int32(0xFFFFFFFF&fsk_sync_word_reg))
+	err = batch.Reg_w(LGW_FSK_REF_PATTERN_LSB, int32(0xFFFFFFFF&fsk_sync_word_reg))
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_REF_PATTERN_MSB, int32(0xFFFFFFFF&(fsk_sync_word_reg>>32)))
+	err = batch.Reg_w(LGW_FSK_REF_PATTERN_MSB, int32(0xFFFFFFFF&(fsk_sync_word_reg>>32)))
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if s.if_enable[9] {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_RADIO_SELECT, int32(s.if_rf_chain[9]))
+		err = batch.Reg_w(LGW_FSK_RADIO_SELECT, int32(s.if_rf_chain[9]))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_BR_RATIO, int32(LGW_XTAL_FREQU/s.fsk_rx_dr)) /* setting the dividing ratio for datarate */
+		err = batch.Reg_w(LGW_FSK_BR_RATIO, int32(LGW_XTAL_FREQU/s.fsk_rx_dr)) /* setting the dividing ratio for datarate */
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_CH_BW_EXPO, int32(s.fsk_rx_bw))
+		err = batch.Reg_w(LGW_FSK_CH_BW_EXPO, int32(s.fsk_rx_bw))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_MODEM_ENABLE, 1) /* default 0 */
+		err = batch.Reg_w(LGW_FSK_MODEM_ENABLE, 1) /* default 0 */
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 	} else {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FSK_MODEM_ENABLE, 0)
+		err = batch.Reg_w(LGW_FSK_MODEM_ENABLE, 0)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 	}
+	err = batch.Commit()
+	if err != nil {
+		return err
+	}
 
 	/* Load firmware */
-	err = Load_firmware(f, MCU_ARB, lgw_spi_mux_mode, spi_mux_target, arb_firmware)
+	err = Load_firmware(c, MCU_ARB, arb_firmware)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Load_firmware(f, MCU_AGC, lgw_spi_mux_mode, spi_mux_target, agc_firmware)
+	err = Load_firmware(c, MCU_AGC, agc_firmware)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
+	emit(START_FIRMWARE_LOADED, nil)
 
 	/* gives the AGC MCU control over radio, RF front-end and filter gain */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FORCE_HOST_RADIO_CTRL, 0)
+	err = Lgw_reg_w(c, LGW_FORCE_HOST_RADIO_CTRL, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FORCE_HOST_FE_CTRL, 0)
+	err = Lgw_reg_w(c, LGW_FORCE_HOST_FE_CTRL, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_FORCE_DEC_FILTER_GAIN, 0)
+	err = Lgw_reg_w(c, LGW_FORCE_DEC_FILTER_GAIN, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* Get MCUs out of reset */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, 0) /* MUST not be = to 1 or 2 at firmware init */
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, 0) /* MUST not be = to 1 or 2 at firmware init */
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_RST_0, 0)
+	err = Lgw_reg_w(c, LGW_MCU_RST_0, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_RST_1, 0)
+	err = Lgw_reg_w(c, LGW_MCU_RST_1, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* Check firmware version */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_ADDR, FW_VERSION_ADDR)
+	err = Lgw_reg_w(c, LGW_DBG_AGC_MCU_RAM_ADDR, FW_VERSION_ADDR)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_AGC_MCU_RAM_DATA)
+	read_val, err = Lgw_reg_r(c, LGW_DBG_AGC_MCU_RAM_DATA)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	fw_version = uint8(read_val)
 	if fw_version != FW_VERSION_AGC {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: Version of AGC firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_AGC)
+		return fmt.Errorf("ERROR: Version of AGC firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_AGC)
 	}
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_ARB_MCU_RAM_ADDR, FW_VERSION_ADDR)
+	err = Lgw_reg_w(c, LGW_DBG_ARB_MCU_RAM_ADDR, FW_VERSION_ADDR)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_DBG_ARB_MCU_RAM_DATA)
+	read_val, err = Lgw_reg_r(c, LGW_DBG_ARB_MCU_RAM_DATA)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	fw_version = uint8(read_val)
 	if fw_version != FW_VERSION_ARB {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: Version of arbiter firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_ARB)
+		return fmt.Errorf("ERROR: Version of arbiter firmware not expected, actual:%d expected:%d\n", fw_version, FW_VERSION_ARB)
 	}
 
 	fmt.Printf("Info: Initialising AGC firmware...\n")
-	time.Sleep(1 * time.Millisecond)
+	err = sleep_ctx(ctx, 1*time.Millisecond)
+	if err != nil {
+		return err
+	}
 
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+	read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if read_val != 0x10 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+		return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 	}
 
 	/* Update Tx gain LUT and start AGC */
 	for i := uint8(0); i < s.txgain_lut.size; i++ {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, AGC_CMD_WAIT) /* start a transaction */
+		err = Lgw_reg_w(c, LGW_RADIO_SELECT, AGC_CMD_WAIT) /* start a transaction */
+		if err != nil {
+			return err
+		}
+		err = sleep_ctx(ctx, 1*time.Millisecond)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		time.Sleep(1 * time.Millisecond)
 		load_val := s.txgain_lut.lut[i].mix_gain + (16 * s.txgain_lut.lut[i].dac_gain) + (64 * s.txgain_lut.lut[i].pa_gain)
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, int32(load_val))
+		err = Lgw_reg_w(c, LGW_RADIO_SELECT, int32(load_val))
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
+		}
+		err = sleep_ctx(ctx, 1*time.Millisecond)
+		if err != nil {
+			return err
 		}
-		time.Sleep(1 * time.Millisecond)
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+		read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 		if read_val != (0x30 + int32(i)) {
-			return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+			return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 		}
 	}
 	/* As the AGC fw is waiting for 16 entries, we need to abort the transaction if we get less entries */
 	if s.txgain_lut.size < TX_GAIN_LUT_SIZE_MAX {
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+		err = Lgw_reg_w(c, LGW_RADIO_SELECT, AGC_CMD_WAIT)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
+		}
+		err = sleep_ctx(ctx, 1*time.Millisecond)
+		if err != nil {
+			return err
 		}
-		time.Sleep(1 * time.Millisecond)
 		load_val := AGC_CMD_ABORT
-		err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, int32(load_val))
+		err = Lgw_reg_w(c, LGW_RADIO_SELECT, int32(load_val))
+		if err != nil {
+			return err
+		}
+		err = sleep_ctx(ctx, 1*time.Millisecond)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
-		time.Sleep(1 * time.Millisecond)
-		read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+		read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, spi_mux_target, err
+			return err
 		}
 		if read_val != 0x30 {
-			return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+			return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 		}
 	}
 
 	/* Load Tx freq MSBs (always 3 if f > 768 for SX1257 or f > 384 for SX1255 */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+	if err != nil {
+		return err
+	}
+	err = sleep_ctx(ctx, 1*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, 3)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, 3)
+	err = sleep_ctx(ctx, 1*time.Millisecond)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+	read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if read_val != 0x33 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+		return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 	}
 
 	/* Load chan_select firmware option */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+	if err != nil {
+		return err
+	}
+	err = sleep_ctx(ctx, 1*time.Millisecond)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, 0)
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, 0)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+	err = sleep_ctx(ctx, 1*time.Millisecond)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
+	}
+	read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
+	if err != nil {
+		return err
 	}
 	if read_val != 0x30 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+		return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 	}
 
 	/* End AGC firmware init and check status */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, AGC_CMD_WAIT)
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, AGC_CMD_WAIT)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
+	}
+	err = sleep_ctx(ctx, 1*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_RADIO_SELECT, int32(radio_select)) /* Load intended value of RADIO_SELECT */
+	if err != nil {
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_RADIO_SELECT, int32(radio_select)) /* Load intended value of RADIO_SELECT */
+	err = sleep_ctx(ctx, 1*time.Millisecond)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
-	time.Sleep(1 * time.Millisecond)
 	fmt.Printf("Info: putting back original RADIO_SELECT value\n")
-	read_val, err = Lgw_reg_r(f, lgw_spi_mux_mode, spi_mux_target, LGW_MCU_AGC_STATUS)
+	read_val, err = Lgw_reg_r(c, LGW_MCU_AGC_STATUS)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 	if read_val != 0x40 {
-		return nil, lgw_spi_mux_mode, spi_mux_target, fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
+		return fmt.Errorf("ERROR: AGC FIRMWARE INITIALIZATION FAILURE, STATUS 0x%02X\n", uint8(read_val))
 	}
+	emit(START_AGC_INITIALISED, nil)
 
 	/* enable GPS event capture */
-	err = Lgw_reg_w(f, lgw_spi_mux_mode, spi_mux_target, LGW_GPS_EN, 1)
+	err = Lgw_reg_w(c, LGW_GPS_EN, 1)
 	if err != nil {
-		return nil, lgw_spi_mux_mode, spi_mux_target, err
+		return err
 	}
 
 	/* */
-	//if lbt_is_enabled() == true {
-	//	printf("INFO: Configuring LBT, this may take few seconds, please wait...\n")
-	//	wait_ms(8400)
-	//}
+	if s.lbt_enable {
+		fmt.Printf("INFO: Configuring LBT, this may take few seconds, please wait...\n")
+		err = sleep_ctx(ctx, 8400*time.Millisecond)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.is_started = true
+	return nil
+}
+
+/* sleep_ctx waits for d, or returns the error of ctx if it is done first */
+func sleep_ctx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func Lgw_stop(c *Concentrator) (err error) {
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
+	/* release the SPI device whatever happens to the shutdown sequence */
+	defer func() {
+		c.is_started = false
+		derr := Lgw_disconnect(c)
+		if derr == nil {
+			return
+		}
+		if err == nil {
+			err = derr
+		} else {
+			err = fmt.Errorf("%s%s", err, derr)
+		}
+	}()
+
+	/* abort any pending or ongoing TX */
+	err = Lgw_abort_tx(c)
+	if err != nil {
+		return err
+	}
+
+	/* put both MCUs in reset */
+	err = Lgw_reg_w(c, LGW_MCU_RST_0, 1)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_MCU_RST_1, 1)
+	if err != nil {
+		return err
+	}
+
+	/* switch the radios off */
+	err = Lgw_reg_w(c, LGW_RADIO_A_EN, 0)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_RADIO_B_EN, 0)
+	if err != nil {
+		return err
+	}
+
+	/* gate clocks */
+	err = Lgw_reg_w(c, LGW_GLOBAL_EN, 0)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_CLK32M_EN, 0)
+	if err != nil {
+		return err
+	}
 
-	return f, lgw_spi_mux_mode, spi_mux_target, nil
+	/* reset the registers */
+	err = Lgw_soft_reset(c)
+	if err != nil {
+		return err
+	}
+	return nil
 }
-func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) error {
+func Lgw_constant_adjust(c *Concentrator) error {
+	s := c.state
+	batch := NewRegBatch(c) /* plain register settings, sent in as few SPI messages as the read-modify-writes allow */
+
 
 	/* I/Q path setup */
 	// Lgw_reg_w(LGW_RX_INVERT_IQ,0); /* default 0 */
@@ -1245,35 +1468,35 @@ func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State
 	// Lgw_reg_w(LGW_RX_EDGE_SELECT,0); /* default 0 */
 	// Lgw_reg_w(LGW_MBWSSF_MODEM_INVERT_IQ,0); /* default 0 */
 	// Lgw_reg_w(LGW_DC_NOTCH_EN,1); /* default 1 */
-	err := Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_BB_FILTER_ALPHA, 6) /* default 7 */
+	err := batch.Reg_w(LGW_RSSI_BB_FILTER_ALPHA, 6) /* default 7 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_DEC_FILTER_ALPHA, 7) /* default 5 */
+	err = batch.Reg_w(LGW_RSSI_DEC_FILTER_ALPHA, 7) /* default 5 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_CHANN_FILTER_ALPHA, 7) /* default 8 */
+	err = batch.Reg_w(LGW_RSSI_CHANN_FILTER_ALPHA, 7) /* default 8 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_BB_DEFAULT_VALUE, 23) /* default 32 */
+	err = batch.Reg_w(LGW_RSSI_BB_DEFAULT_VALUE, 23) /* default 32 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_CHANN_DEFAULT_VALUE, 85) /* default 100 */
+	err = batch.Reg_w(LGW_RSSI_CHANN_DEFAULT_VALUE, 85) /* default 100 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RSSI_DEC_DEFAULT_VALUE, 66) /* default 100 */
+	err = batch.Reg_w(LGW_RSSI_DEC_DEFAULT_VALUE, 66) /* default 100 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_DEC_GAIN_OFFSET, 7) /* default 8 */
+	err = batch.Reg_w(LGW_DEC_GAIN_OFFSET, 7) /* default 8 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_CHAN_GAIN_OFFSET, 6) /* default 7 */
+	err = batch.Reg_w(LGW_CHAN_GAIN_OFFSET, 6) /* default 7 */
 	if err != nil {
 		return err
 	}
@@ -1303,25 +1526,25 @@ func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State
 	// Lgw_reg_w(LGW_FRAME_SYNCH_GAIN,1); /* default 1 */
 	// Lgw_reg_w(LGW_SYNCH_DETECT_TH,1); /* default 1 */
 	// Lgw_reg_w(LGW_ZERO_PAD,0); /* default 0 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_SNR_AVG_CST, 3) /* default 2 */
+	err = batch.Reg_w(LGW_SNR_AVG_CST, 3) /* default 2 */
 	if err != nil {
 		return err
 	}
 	if s.lorawan_public { /* LoRa network */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
+		err = batch.Reg_w(LGW_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
+		err = batch.Reg_w(LGW_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
 		if err != nil {
 			return err
 		}
 	} else { /* private network */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
+		err = batch.Reg_w(LGW_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
+		err = batch.Reg_w(LGW_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
 		if err != nil {
 			return err
 		}
@@ -1342,20 +1565,20 @@ func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State
 	// Lgw_reg_w(LGW_MBWSSF_SYNCH_DETECT_TH,1); /* default 1 */
 	// Lgw_reg_w(LGW_MBWSSF_ZERO_PAD,0); /* default 0 */
 	if s.lorawan_public { /* LoRa network */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
+		err = batch.Reg_w(LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
+		err = batch.Reg_w(LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
 		if err != nil {
 			return err
 		}
 	} else {
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
+		err = batch.Reg_w(LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
+		err = batch.Reg_w(LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
 		if err != nil {
 			return err
 		}
@@ -1367,90 +1590,90 @@ func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State
 	// Lgw_reg_w(LGW_MBWSSF_AGC_FREEZE_ON_DETECT,1); /* default 1 */
 
 	/* Improvement of reference clock frequency error tolerance */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_ADJUST_MODEM_START_OFFSET_RDX4, 1) /* default 0 */
+	err = batch.Reg_w(LGW_ADJUST_MODEM_START_OFFSET_RDX4, 1) /* default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_ADJUST_MODEM_START_OFFSET_SF12_RDX4, 4094) /* default 4092 */
+	err = batch.Reg_w(LGW_ADJUST_MODEM_START_OFFSET_SF12_RDX4, 4094) /* default 4092 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_CORR_MAC_GAIN, 7) /* default 5 */
+	err = batch.Reg_w(LGW_CORR_MAC_GAIN, 7) /* default 5 */
 	if err != nil {
 		return err
 	}
 
 	/* FSK datapath setup */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_RX_INVERT, 1) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_RX_INVERT, 1) /* default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_MODEM_INVERT_IQ, 1) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_MODEM_INVERT_IQ, 1) /* default 0 */
 	if err != nil {
 		return err
 	}
 
 	/* FSK demodulator setup */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_RSSI_LENGTH, 4) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_RSSI_LENGTH, 4) /* default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_PKT_MODE, 1) /* variable length, default 0 */
+	err = batch.Reg_w(LGW_FSK_PKT_MODE, 1) /* variable length, default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_CRC_EN, 1) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_CRC_EN, 1) /* default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_DCFREE_ENC, 2) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_DCFREE_ENC, 2) /* default 0 */
 	if err != nil {
 		return err
 	}
 	// Lgw_reg_w(LGW_FSK_CRC_IBM,0); /* default 0 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_ERROR_OSR_TOL, 10) /* default 0 */
+	err = batch.Reg_w(LGW_FSK_ERROR_OSR_TOL, 10) /* default 0 */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_PKT_LENGTH, 255) /* max packet length in variable length mode */
+	err = batch.Reg_w(LGW_FSK_PKT_LENGTH, 255) /* max packet length in variable length mode */
 	if err != nil {
 		return err
 	}
 	// Lgw_reg_w(LGW_FSK_NODE_ADRS,0); /* default 0 */
 	// Lgw_reg_w(LGW_FSK_BROADCAST,0); /* default 0 */
 	// Lgw_reg_w(LGW_FSK_AUTO_AFC_ON,0); /* default 0 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_PATTERN_TIMEOUT_CFG, 128) /* sync timeout (allow 8 bytes preamble + 8 bytes sync word, default 0 */
+	err = batch.Reg_w(LGW_FSK_PATTERN_TIMEOUT_CFG, 128) /* sync timeout (allow 8 bytes preamble + 8 bytes sync word, default 0 */
 	if err != nil {
 		return err
 	}
 
 	/* TX general parameters */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_START_DELAY, TX_START_DELAY_DEFAULT) /* default 0 */
+	err = batch.Reg_w(LGW_TX_START_DELAY, TX_START_DELAY_DEFAULT) /* default 0 */
 	if err != nil {
 		return err
 	}
 
 	/* TX LoRa */
 	// Lgw_reg_w(LGW_TX_MODE,0); /* default 0 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_SWAP_IQ, 1) /* "normal" polarity; default 0 */
+	err = batch.Reg_w(LGW_TX_SWAP_IQ, 1) /* "normal" polarity; default 0 */
 	if err != nil {
 		return err
 	}
 	if s.lorawan_public { /* LoRa network */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
+		err = batch.Reg_w(LGW_TX_FRAME_SYNCH_PEAK1_POS, 3) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
+		err = batch.Reg_w(LGW_TX_FRAME_SYNCH_PEAK2_POS, 4) /* default 2 */
 		if err != nil {
 			return err
 		}
 	} else { /* Private network */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
+		err = batch.Reg_w(LGW_TX_FRAME_SYNCH_PEAK1_POS, 1) /* default 1 */
 		if err != nil {
 			return err
 		}
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_TX_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
+		err = batch.Reg_w(LGW_TX_FRAME_SYNCH_PEAK2_POS, 2) /* default 2 */
 		if err != nil {
 			return err
 		}
@@ -1458,14 +1681,14 @@ func Lgw_constant_adjust(c *os.File, spi_mux_mode, spi_mux_target byte, s *State
 
 	/* TX FSK */
 	// Lgw_reg_w(LGW_FSK_TX_GAUSSIAN_EN,1); /* default 1 */
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_FSK_TX_GAUSSIAN_SELECT_BT, 2) /* Gaussian filter always on TX, default 0 */
+	err = batch.Reg_w(LGW_FSK_TX_GAUSSIAN_SELECT_BT, 2) /* Gaussian filter always on TX, default 0 */
 	if err != nil {
 		return err
 	}
 	// Lgw_reg_w(LGW_FSK_TX_PATTERN_EN,1); /* default 1 */
 	// Lgw_reg_w(LGW_FSK_TX_PREAMBLE_SEQ,0); /* default 0 */
 
-	return nil
+	return batch.Commit()
 }
 
 /**
@@ -1491,7 +1714,9 @@ type Lgw_pkt_rx_s struct {
 	Payload    []byte  /*!> buffer containing the payload */
 }
 
-func Lgw_receive(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) ([]Lgw_pkt_rx_s, error) {
+func Lgw_receive(c *Concentrator) ([]Lgw_pkt_rx_s, error) {
+	s := c.state
+
 	//int nb_pkt_fetch; /* loop variable and return value */
 	//struct lgw_pkt_rx_s *p; /* pointer to the current structure in the struct array */
 	//uint8_t buff[255+RX_METADATA_NB]; /* buffer to store the result of SPI read bursts */
@@ -1505,14 +1730,18 @@ func Lgw_receive(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) ([]Lgw
 
 	pkt_data := make([]Lgw_pkt_rx_s, 16)
 
+	/* fetch the RX FIFO status of the first packet */
+	fifo, err := Lgw_reg_rb(c, LGW_RX_PACKET_DATA_FIFO_NUM_STORED, 5)
+	if err != nil {
+		return nil, err
+	}
+
 	/* iterate max_pkt times at most */
-	for nb_pkt_fetch := 0; nb_pkt_fetch < 16; nb_pkt_fetch++ {
+	var nb_pkt_fetch int
+	for nb_pkt_fetch = 0; nb_pkt_fetch < 16; nb_pkt_fetch++ {
 
 		/* fetch all the RX FIFO data */
-		buff, err := Lgw_reg_rb(c, spi_mux_mode, spi_mux_target, LGW_RX_PACKET_DATA_FIFO_NUM_STORED, 5)
-		if err != nil {
-			return nil, err
-		}
+		buff := fifo
 		/* 0:   number of packets available in RX data buffer */
 		/* 1,2: start address of the current packet in RX data buffer */
 		/* 3:   CRC status of the current packet */
@@ -1534,7 +1763,7 @@ func Lgw_receive(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) ([]Lgw
 		stat_fifo := buff[3] /* will be used later, need to save it before overwriting buff */
 
 		/* get payload + metadata */
-		buff, err = Lgw_reg_rb(c, spi_mux_mode, spi_mux_target, LGW_RX_DATA_BUF_DATA, sz+RX_METADATA_NB)
+		buff, err = Lgw_reg_rb(c, LGW_RX_DATA_BUF_DATA, sz+RX_METADATA_NB)
 		if err != nil {
 			return nil, err
 		}
@@ -1641,8 +1870,6 @@ func Lgw_receive(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) ([]Lgw
 					break
 				default:
 					return nil, fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", pkt_data[nb_pkt_fetch].Bandwidth)
-					delay_x = 0
-					bw_pow = 0
 				}
 			} else { /* packet was received on one of the sensor channels = 125kHz */
 				delay_x = 114
@@ -1710,9 +1937,518 @@ func Lgw_receive(c *os.File, spi_mux_mode, spi_mux_target byte, s *State) ([]Lgw
 		pkt_data[nb_pkt_fetch].Count_us = uint32(int(raw_timestamp) - timestamp_correction)
 		pkt_data[nb_pkt_fetch].Crc = uint16(buff[sz+10]) + (uint16(buff[sz+11]) << 8)
 
-		/* advance packet FIFO */
-		err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, LGW_RX_PACKET_DATA_FIFO_NUM_STORED, 0)
+		/* advance packet FIFO, and fetch the status of the next packet in the same SPI transaction */
+		batch := NewRegBatch(c)
+		err = batch.Reg_w(LGW_RX_PACKET_DATA_FIFO_NUM_STORED, 0)
+		if err != nil {
+			return nil, err
+		}
+		fifo, err = batch.Reg_rb(LGW_RX_PACKET_DATA_FIFO_NUM_STORED, 5)
+		if err != nil {
+			return nil, err
+		}
+		err = batch.Commit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pkt_data[:nb_pkt_fetch], nil
+}
+
+/**
+@struct lgw_pkt_tx_s
+@brief Structure containing the configuration of a packet to send and a pointer to the payload
+*/
+type Lgw_pkt_tx_s struct {
+	Freq_hz    uint32 /*!> center frequency of TX */
+	Tx_mode    byte   /*!> select on what event/time the TX is triggered */
+	Count_us   uint32 /*!> timestamp or delay in microseconds for TX trigger */
+	Rf_chain   byte   /*!> through which RF chain will the packet be sent */
+	Rf_power   int8   /*!> TX power, in dBm */
+	Modulation byte   /*!> modulation to use for the packet */
+	Bandwidth  byte   /*!> modulation bandwidth (LoRa only) */
+	Datarate   uint32 /*!> TX datarate (baudrate for FSK, SF for LoRa) */
+	Coderate   byte   /*!> error-correcting code of the packet (LoRa only) */
+	Invert_pol bool   /*!> invert signal polarity, for orthogonal downlinks (LoRa only) */
+	F_dev      byte   /*!> frequency deviation, in kHz (FSK only) */
+	Preamble   uint16 /*!> set the preamble length, 0 for default */
+	No_crc     bool   /*!> if true, do not send a CRC in the packet */
+	No_header  bool   /*!> if true, enable implicit header mode (LoRa), fixed length (FSK) */
+	Size       uint16 /*!> payload size in bytes */
+	Payload    []byte /*!> buffer containing the payload */
+}
+
+func Lgw_get_tx_start_delay(c *Concentrator, tx_notch_enable bool, bw byte) uint16 {
+	var notch_delay_us float64
+	var bw_delay_us float64
+
+	/* Notch filtering performed by FPGA adds a constant delay (group delay) that we need to compensate */
+	if tx_notch_enable {
+		notch_delay_us = lgw_fpga_get_tx_notch_delay(c.tx_notch_support, c.tx_notch_offset)
+	}
+
+	/* Calibrated delay brought by SX1301 depending on signal bandwidth */
+	switch bw {
+	case BW_125KHZ:
+		bw_delay_us = 1.5
+	case BW_500KHZ:
+		/* Intended fall-through: it is the calibrated reference */
+	default:
+	}
+
+	tx_start_delay := float64(TX_START_DELAY_DEFAULT) - bw_delay_us - notch_delay_us
+
+	return uint16(tx_start_delay) /* keep truncating instead of rounding: better behaviour measured */
+}
+
+func Lgw_abort_tx(c *Concentrator) error {
+	return Lgw_reg_w(c, LGW_TX_TRIG_ALL, 0)
+}
+
+func Lgw_send(c *Concentrator, pkt_data Lgw_pkt_tx_s) error {
+	s := c.state
+
+	var part_int, part_frac uint32 /* integer and fractional part for PLL register value calculation */
+	buff := make([]byte, 256+TX_METADATA_NB) /* buffer to prepare the packet to send + metadata before SPI write burst */
+
+	/* check if the concentrator is running */
+	if c.is_started == false {
+		return fmt.Errorf("ERROR: CONCENTRATOR IS NOT RUNNING, START IT BEFORE SENDING\n")
+	}
+
+	/* check input range (segfault prevention) */
+	if pkt_data.Rf_chain >= LGW_RF_CHAIN_NB {
+		return fmt.Errorf("ERROR: INVALID RF_CHAIN TO SEND PACKETS\n")
+	}
+
+	/* check input variables */
+	if s.rf_tx_enable[pkt_data.Rf_chain] == false {
+		return fmt.Errorf("ERROR: SELECTED RF_CHAIN IS DISABLED FOR TX ON SELECTED BOARD\n")
+	}
+	if s.rf_enable[pkt_data.Rf_chain] == false {
+		return fmt.Errorf("ERROR: SELECTED RF_CHAIN IS DISABLED\n")
+	}
+	if !IS_TX_MODE(pkt_data.Tx_mode) {
+		return fmt.Errorf("ERROR: TX_MODE NOT SUPPORTED\n")
+	}
+	if int(pkt_data.Size) > len(pkt_data.Payload) {
+		return fmt.Errorf("ERROR: PAYLOAD SHORTER THAN PACKET SIZE\n")
+	}
+	if pkt_data.Modulation == MOD_LORA {
+		if !IS_LORA_BW(pkt_data.Bandwidth) {
+			return fmt.Errorf("ERROR: BANDWIDTH NOT SUPPORTED BY LORA TX\n")
+		}
+		if !IS_LORA_STD_DR(pkt_data.Datarate) {
+			return fmt.Errorf("ERROR: DATARATE NOT SUPPORTED BY LORA TX\n")
+		}
+		if !IS_LORA_CR(pkt_data.Coderate) {
+			return fmt.Errorf("ERROR: CODERATE NOT SUPPORTED BY LORA TX\n")
+		}
+		if pkt_data.Size > 255 {
+			return fmt.Errorf("ERROR: PAYLOAD LENGTH TOO BIG FOR LORA TX\n")
+		}
+	} else if pkt_data.Modulation == MOD_FSK {
+		if (pkt_data.F_dev < 1) || (pkt_data.F_dev > 200) {
+			return fmt.Errorf("ERROR: TX FREQUENCY DEVIATION OUT OF ACCEPTABLE RANGE\n")
+		}
+		if !IS_FSK_DR(pkt_data.Datarate) {
+			return fmt.Errorf("ERROR: DATARATE NOT SUPPORTED BY FSK IF CHAIN\n")
+		}
+		if pkt_data.Size > 255 {
+			return fmt.Errorf("ERROR: PAYLOAD LENGTH TOO BIG FOR FSK TX\n")
+		}
+	} else {
+		return fmt.Errorf("ERROR: INVALID TX MODULATION\n")
+	}
+
+	/* Enable notch filter for LoRa 125kHz */
+	tx_notch_enable := (pkt_data.Modulation == MOD_LORA) && (pkt_data.Bandwidth == BW_125KHZ)
+
+	/* Get the TX start delay to be applied for this TX */
+	tx_start_delay := Lgw_get_tx_start_delay(c, tx_notch_enable, pkt_data.Bandwidth)
+
+	/* interpretation of TX power */
+	if s.txgain_lut.size == 0 {
+		return fmt.Errorf("ERROR: TX GAIN LUT IS EMPTY\n")
+	}
+	pow_index := s.txgain_lut.size - 1
+	for ; pow_index > 0; pow_index-- {
+		if s.txgain_lut.lut[pow_index].rf_power <= pkt_data.Rf_power {
+			break
+		}
+	}
+
+	/* loading TX imbalance correction */
+	target_mix_gain := s.txgain_lut.lut[pow_index].mix_gain
+	if (target_mix_gain < 8) || (target_mix_gain > 15) {
+		return fmt.Errorf("ERROR: TX GAIN LUT MIXER GAIN %d OUT OF CALIBRATED RANGE\n", target_mix_gain)
+	}
+	var offset_i, offset_q int8
+	if pkt_data.Rf_chain == 0 { /* use radio A calibration table */
+		offset_i = c.cal_offset_a_i[target_mix_gain-8]
+		offset_q = c.cal_offset_a_q[target_mix_gain-8]
+	} else { /* use radio B calibration table */
+		offset_i = c.cal_offset_b_i[target_mix_gain-8]
+		offset_q = c.cal_offset_b_q[target_mix_gain-8]
+	}
+	err := Lgw_reg_w(c, LGW_TX_OFFSET_I, int32(offset_i))
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_w(c, LGW_TX_OFFSET_Q, int32(offset_q))
+	if err != nil {
+		return err
+	}
+
+	/* Set digital gain from LUT */
+	err = Lgw_reg_w(c, LGW_TX_GAIN, int32(s.txgain_lut.lut[pow_index].dig_gain))
+	if err != nil {
+		return err
+	}
+
+	/* fixed metadata, useful payload and misc metadata compositing */
+	transfer_size := TX_METADATA_NB + int(pkt_data.Size)
+	payload_offset := TX_METADATA_NB /* start the payload just after the metadata */
+
+	/* metadata 0 to 2, TX PLL frequency */
+	switch s.rf_radio_type[0] { /* we assume that there is only one radio type on the board */
+	case LGW_RADIO_TYPE_SX1255:
+		part_int = pkt_data.Freq_hz / (SX125x_32MHz_FRAC << 7)                               /* integer part, gives the MSB */
+		part_frac = ((pkt_data.Freq_hz % (SX125x_32MHz_FRAC << 7)) << 9) / SX125x_32MHz_FRAC /* fractional part, gives middle part and LSB */
+	case LGW_RADIO_TYPE_SX1257:
+		part_int = pkt_data.Freq_hz / (SX125x_32MHz_FRAC << 8)                               /* integer part, gives the MSB */
+		part_frac = ((pkt_data.Freq_hz % (SX125x_32MHz_FRAC << 8)) << 8) / SX125x_32MHz_FRAC /* fractional part, gives middle part and LSB */
+	default:
+		return fmt.Errorf("ERROR: UNEXPECTED VALUE %d FOR RADIO TYPE\n", s.rf_radio_type[0])
+	}
+
+	buff[0] = byte(0xFF & part_int)         /* Most Significant Byte */
+	buff[1] = byte(0xFF & (part_frac >> 8)) /* middle byte */
+	buff[2] = byte(0xFF & part_frac)        /* Least Significant Byte */
+
+	/* metadata 3 to 6, timestamp trigger value */
+	/* TX state machine must be triggered at (T0 - tx_start_delay) for packet to start being emitted at T0 */
+	if pkt_data.Tx_mode == TIMESTAMPED {
+		count_trig := pkt_data.Count_us - uint32(tx_start_delay)
+		buff[3] = byte(0xFF & (count_trig >> 24))
+		buff[4] = byte(0xFF & (count_trig >> 16))
+		buff[5] = byte(0xFF & (count_trig >> 8))
+		buff[6] = byte(0xFF & count_trig)
+	}
+
+	/* parameters depending on modulation  */
+	if pkt_data.Modulation == MOD_LORA {
+		/* metadata 7, modulation type, radio chain selection and TX power */
+		buff[7] = (0x20 & (pkt_data.Rf_chain << 5)) | (0x0F & pow_index) /* bit 4 is 0 -> LoRa modulation */
+
+		buff[8] = 0 /* metadata 8, not used */
+
+		/* metadata 9, CRC, LoRa CR & SF */
+		switch pkt_data.Datarate {
+		case DR_LORA_SF7:
+			buff[9] = 7
+		case DR_LORA_SF8:
+			buff[9] = 8
+		case DR_LORA_SF9:
+			buff[9] = 9
+		case DR_LORA_SF10:
+			buff[9] = 10
+		case DR_LORA_SF11:
+			buff[9] = 11
+		case DR_LORA_SF12:
+			buff[9] = 12
+		}
+		switch pkt_data.Coderate {
+		case CR_LORA_4_5:
+			buff[9] |= 1 << 4
+		case CR_LORA_4_6:
+			buff[9] |= 2 << 4
+		case CR_LORA_4_7:
+			buff[9] |= 3 << 4
+		case CR_LORA_4_8:
+			buff[9] |= 4 << 4
+		}
+		if pkt_data.No_crc == false {
+			buff[9] |= 0x80 /* set 'CRC enable' bit */
+		}
+
+		/* metadata 10, payload size */
+		buff[10] = byte(pkt_data.Size)
+
+		/* metadata 11, implicit header, modulation bandwidth, PPM offset & polarity */
+		switch pkt_data.Bandwidth {
+		case BW_125KHZ:
+			buff[11] = 0
+		case BW_250KHZ:
+			buff[11] = 1
+		case BW_500KHZ:
+			buff[11] = 2
+		}
+		if pkt_data.No_header {
+			buff[11] |= 0x04 /* set 'implicit header' bit */
+		}
+		if SET_PPM_ON(pkt_data.Bandwidth, byte(pkt_data.Datarate)) {
+			buff[11] |= 0x08 /* set 'PPM offset' bit at 1 */
+		}
+		if pkt_data.Invert_pol {
+			buff[11] |= 0x10 /* set 'TX polarity' bit at 1 */
+		}
+
+		/* metadata 12 & 13, LoRa preamble size */
+		if pkt_data.Preamble == 0 { /* if not explicit, use recommended LoRa preamble size */
+			pkt_data.Preamble = STD_LORA_PREAMBLE
+		} else if pkt_data.Preamble < MIN_LORA_PREAMBLE { /* enforce minimum preamble size */
+			pkt_data.Preamble = MIN_LORA_PREAMBLE
+		}
+		buff[12] = byte(0xFF & (pkt_data.Preamble >> 8))
+		buff[13] = byte(0xFF & pkt_data.Preamble)
+
+		/* metadata 14 & 15, not used */
+		buff[14] = 0
+		buff[15] = 0
+
+		/* MSB of RF frequency is now used in AGC firmware to implement large/narrow filtering in SX1257/55 */
+		buff[0] &= 0x3F /* Unset 2 MSBs of frequency code */
+		if pkt_data.Bandwidth == BW_500KHZ {
+			buff[0] |= 0x80 /* Set MSB bit to enlarge analog filter for 500kHz BW */
+		}
+
+		/* Set MSB-1 bit to enable digital filter if required */
+		if tx_notch_enable {
+			buff[0] |= 0x40
+		}
+	} else {
+		/* metadata 7, modulation type, radio chain selection and TX power */
+		buff[7] = (0x20 & (pkt_data.Rf_chain << 5)) | 0x10 | (0x0F & pow_index) /* bit 4 is 1 -> FSK modulation */
+
+		buff[8] = 0 /* metadata 8, not used */
+
+		/* metadata 9, frequency deviation */
+		buff[9] = pkt_data.F_dev
+
+		/* metadata 10, payload size */
+		buff[10] = byte(pkt_data.Size)
+
+		/* metadata 11, packet mode, CRC, encoding */
+		buff[11] = 0x01 | (0x02 << 2) /* always in variable length packet mode, whitening, and CCITT CRC if CRC is not disabled  */
+		if pkt_data.No_crc == false {
+			buff[11] |= 0x02
+		}
+
+		/* metadata 12 & 13, FSK preamble size */
+		if pkt_data.Preamble == 0 { /* if not explicit, use LoRa MAC preamble size */
+			pkt_data.Preamble = STD_FSK_PREAMBLE
+		} else if pkt_data.Preamble < MIN_FSK_PREAMBLE { /* enforce minimum preamble size */
+			pkt_data.Preamble = MIN_FSK_PREAMBLE
+		}
+		buff[12] = byte(0xFF & (pkt_data.Preamble >> 8))
+		buff[13] = byte(0xFF & pkt_data.Preamble)
+
+		/* metadata 14 & 15, FSK baudrate */
+		fsk_dr_div := uint16(LGW_XTAL_FREQU / pkt_data.Datarate) /* Ok for datarate between 500bps and 250kbps */
+		buff[14] = byte(0xFF & (fsk_dr_div >> 8))
+		buff[15] = byte(0xFF & fsk_dr_div)
+
+		/* insert payload size in the packet for variable mode */
+		buff[16] = byte(pkt_data.Size)
+		transfer_size++  /* one more byte to transfer to the TX modem */
+		payload_offset++ /* start the payload with one more byte of offset */
+
+		/* MSB of RF frequency is now used in AGC firmware to implement large/narrow filtering in SX1257/55 */
+		buff[0] &= 0x7F /* Always use narrow band for FSK (force MSB to 0) */
+	}
+
+	/* Configure TX start delay based on TX notch filter */
+	err = Lgw_reg_w(c, LGW_TX_START_DELAY, int32(tx_start_delay))
+	if err != nil {
+		return err
+	}
+
+	/* copy payload from user struct to buffer containing metadata */
+	copy(buff[payload_offset:], pkt_data.Payload[:pkt_data.Size])
+
+	/* reset TX command flags */
+	err = Lgw_abort_tx(c)
+	if err != nil {
+		return err
+	}
+
+	/* put metadata + payload in the TX data buffer */
+	err = Lgw_reg_w(c, LGW_TX_DATA_BUF_ADDR, 0)
+	if err != nil {
+		return err
+	}
+	err = Lgw_reg_wb(c, LGW_TX_DATA_BUF_DATA, buff[:transfer_size])
+	if err != nil {
+		return err
+	}
+
+	/* with LBT, a packet is only sent if the channel was free long enough before it ends */
+	if s.lbt_enable {
+		var tx_start_time uint32
+		switch pkt_data.Tx_mode {
+		case TIMESTAMPED:
+			tx_start_time = pkt_data.Count_us
+		case ON_GPS:
+			sx1301_time, err := Lgw_get_trigcnt(c)
+			if err != nil {
+				return err
+			}
+			tx_start_time = sx1301_time + uint32(tx_start_delay) + 1000000
+		default:
+			return fmt.Errorf("ERROR: tx_mode IMMEDIATE is not supported when LBT is enabled\n")
+		}
+		if pkt_data.Modulation != MOD_LORA {
+			return fmt.Errorf("ERROR: TX IS NOT ALLOWED FOR THIS MODULATION WITH LBT (0x%02X)\n", pkt_data.Modulation)
+		}
+		tx_allowed, err := lbt_is_channel_free(c, pkt_data.Freq_hz, pkt_data.Bandwidth, tx_start_time, Lgw_time_on_air(s, pkt_data)*1000)
+		if err != nil {
+			return fmt.Errorf("ERROR: Failed to check channel availability for TX\n")
+		}
+		if !tx_allowed {
+			return fmt.Errorf("ERROR: Cannot send packet, channel is busy (LBT)\n")
+		}
+	}
+
+	switch pkt_data.Tx_mode {
+	case IMMEDIATE:
+		err = Lgw_reg_w(c, LGW_TX_TRIG_IMMEDIATE, 1)
+	case TIMESTAMPED:
+		err = Lgw_reg_w(c, LGW_TX_TRIG_DELAYED, 1)
+	case ON_GPS:
+		err = Lgw_reg_w(c, LGW_TX_TRIG_GPS, 1)
+	default:
+		return fmt.Errorf("ERROR: UNEXPECTED VALUE %d IN SWITCH STATEMENT\n", pkt_data.Tx_mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/* Lgw_time_on_air returns the time on air of a packet in milliseconds, 0 if it cannot be computed */
+func Lgw_time_on_air(s *State, packet Lgw_pkt_tx_s) uint32 {
+	if packet.Modulation == MOD_LORA {
+		var BW float64 /* kHz */
+		switch packet.Bandwidth {
+		case BW_125KHZ:
+			BW = 125
+		case BW_250KHZ:
+			BW = 250
+		case BW_500KHZ:
+			BW = 500
+		default:
+			fmt.Printf("ERROR: Cannot compute time on air for this packet, unsupported bandwidth (0x%02X)\n", packet.Bandwidth)
+			return 0
+		}
+		var SF float64
+		switch packet.Datarate {
+		case DR_LORA_SF7:
+			SF = 7
+		case DR_LORA_SF8:
+			SF = 8
+		case DR_LORA_SF9:
+			SF = 9
+		case DR_LORA_SF10:
+			SF = 10
+		case DR_LORA_SF11:
+			SF = 11
+		case DR_LORA_SF12:
+			SF = 12
+		default:
+			fmt.Printf("ERROR: Cannot compute time on air for this packet, unsupported datarate (0x%02X)\n", packet.Datarate)
+			return 0
+		}
+
+		/* Duration of 1 symbol */
+		Tsym := math.Pow(2, SF) / BW
+		/* Duration of preamble */
+		Tpreamble := (float64(packet.Preamble) + 4.25) * Tsym
+		/* Duration of payload */
+		H := 0.0 /* header is always enabled, except for beacons */
+		if packet.No_header {
+			H = 1
+		}
+		DE := 0.0 /* Low datarate optimization enabled for SF11 and SF12 */
+		if SF >= 11 {
+			DE = 1
+		}
+		payloadSymbNb := 8 + (math.Ceil((8*float64(packet.Size)-4*SF+28+16-20*H)/(4*(SF-2*DE))) * float64(packet.Coderate+4))
+		Tpayload := payloadSymbNb * Tsym
+
+		return uint32(Tpreamble + Tpayload)
+	} else if packet.Modulation == MOD_FSK {
+		/* PREAMBLE + SYNC_WORD + PKT_LEN + PKT_PAYLOAD + CRC */
+		crc := 2.0
+		if packet.No_crc {
+			crc = 0
+		}
+		Tfsk := (8 * (float64(packet.Preamble) + float64(s.fsk_sync_word_size) + 1 + float64(packet.Size) + crc) / float64(packet.Datarate)) * 1E3
+		return uint32(Tfsk) + 1 /* add margin for rounding */
+	}
+	fmt.Printf("ERROR: Cannot compute time on air for this packet, unsupported modulation (0x%02X)\n", packet.Modulation)
+	return 0
+}
+
+func Lgw_status(c *Concentrator, sel byte) (byte, error) {
+	if sel == TX_STATUS {
+		if c.is_started == false {
+			return TX_OFF, nil
+		}
+		read_value, err := Lgw_reg_r(c, LGW_TX_STATUS)
+		if err != nil {
+			return TX_STATUS_UNKNOWN, err
+		}
+		if TAKE_N_BITS_FROM(byte(read_value), 4, 1) == 0 { /* bit 4 @1: TX programmed */
+			return TX_FREE, nil
+		} else if TAKE_N_BITS_FROM(byte(read_value), 5, 2) != 0 { /* bit 5 or 6 @1: TX sequence */
+			return TX_EMITTING, nil
+		} else {
+			return TX_SCHEDULED, nil
+		}
+	} else if sel == RX_STATUS {
+		if c.is_started == false {
+			return RX_OFF, nil
+		}
+		return RX_STATUS_UNKNOWN, nil /* TODO */
+	}
+	return 0, fmt.Errorf("ERROR: SELECTION INVALID, NO STATUS TO RETURN\n")
+}
+
+/* Return value of internal counter when latest event (eg GPS pulse) was captured */
+func Lgw_get_trigcnt(c *Concentrator) (uint32, error) {
+	if c.transport == nil {
+		return 0, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
+	val, err := Lgw_reg_r(c, LGW_TIMESTAMP)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(val), nil
+}
+
+/* Return instantaneous value of internal counter */
+func Lgw_get_instcnt(c *Concentrator) (uint32, error) {
+	if c.transport == nil {
+		return 0, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
 	}
 
-	return pkt_data, nil
+	/* disable GPS event capture so that LGW_TIMESTAMP follows the free-running counter */
+	err := Lgw_reg_w(c, LGW_GPS_EN, 0)
+	if err != nil {
+		return 0, err
+	}
+	val, err := Lgw_reg_r(c, LGW_TIMESTAMP)
+	if err != nil {
+		return 0, err
+	}
+	/* restore GPS event capture */
+	err = Lgw_reg_w(c, LGW_GPS_EN, 1)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(val), nil
 }
@@ -0,0 +1,242 @@
+package liblorago
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+/* emulated starts a concentrator on an emulated board, with or without FPGA */
+func emulated(t *testing.T, with_fpga bool) (*Concentrator, *Emulator) {
+	t.Helper()
+	s, err := ParseConfig("testdata/global_conf.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEmulator(with_fpga)
+	c := NewConcentratorTransport(e, s)
+	err = Lgw_start(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Lgw_stop(c)
+	})
+	return c, e
+}
+
+func boards(t *testing.T, fn func(t *testing.T, with_fpga bool)) {
+	for _, b := range []struct {
+		name      string
+		with_fpga bool
+	}{{"sx1301", false}, {"fpga", true}} {
+		b := b
+		t.Run(b.name, func(t *testing.T) {
+			fn(t, b.with_fpga)
+		})
+	}
+}
+
+func lora_tx(tx_mode byte, count_us uint32, payload []byte) Lgw_pkt_tx_s {
+	return Lgw_pkt_tx_s{
+		Freq_hz:    867500000,
+		Tx_mode:    tx_mode,
+		Count_us:   count_us,
+		Rf_chain:   0,
+		Rf_power:   14,
+		Modulation: MOD_LORA,
+		Bandwidth:  BW_125KHZ,
+		Datarate:   DR_LORA_SF7,
+		Coderate:   CR_LORA_4_5,
+		Preamble:   8,
+		Size:       uint16(len(payload)),
+		Payload:    payload,
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, _ := emulated(t, with_fpga)
+		want := byte(LGW_SPI_MUX_MODE0)
+		if with_fpga {
+			want = LGW_SPI_MUX_MODE1
+		}
+		if c.SpiMuxMode() != want {
+			t.Errorf("SPI mux mode %d, want %d", c.SpiMuxMode(), want)
+		}
+		if (c.FPGA() != nil) != with_fpga {
+			t.Errorf("FPGA info %v with FPGA %v", c.FPGA(), with_fpga)
+		}
+		if c.Calibration() == nil || !c.Calibration().Finished {
+			t.Errorf("calibration %+v", c.Calibration())
+		}
+		err := Lgw_stop(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Lgw_stop(c)
+		if err == nil {
+			t.Error("second stop succeeded")
+		}
+	})
+}
+
+/* a start cancelled midway returns the error of ctx and releases the SPI link, the next start works */
+func TestStartCancel(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		s, err := ParseConfig("testdata/global_conf.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewConcentratorTransport(NewEmulator(with_fpga), s)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err = Lgw_start_ctx(ctx, c, func(ev Start_event) {
+			if ev.Stage == START_RADIOS_SET_UP {
+				cancel()
+			}
+		})
+		if err != context.Canceled {
+			t.Fatalf("cancelled start returned %v", err)
+		}
+		if c.transport != nil {
+			t.Error("SPI link kept by a cancelled start")
+		}
+		err = Lgw_start(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Lgw_stop(c)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, _ := emulated(t, with_fpga)
+		st, err := Lgw_status(c, TX_STATUS)
+		if err != nil || st != TX_FREE {
+			t.Errorf("TX status %d, %v, want %d", st, err, TX_FREE)
+		}
+		_, err = Lgw_status(c, TX_STATUS|RX_STATUS)
+		if err == nil {
+			t.Error("invalid selection accepted")
+		}
+		Lgw_stop(c)
+		st, err = Lgw_status(c, TX_STATUS)
+		if err != nil || st != TX_OFF {
+			t.Errorf("TX status %d, %v after stop, want %d", st, err, TX_OFF)
+		}
+		st, err = Lgw_status(c, RX_STATUS)
+		if err != nil || st != RX_OFF {
+			t.Errorf("RX status %d, %v after stop, want %d", st, err, RX_OFF)
+		}
+	})
+}
+
+func TestReceive(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, e := emulated(t, with_fpga)
+		p, err := Lgw_receive(c)
+		if err != nil || len(p) != 0 {
+			t.Fatalf("empty FIFO gave %d packets, %v", len(p), err)
+		}
+		e.Inject_lora_packet(3, 7, CR_LORA_4_5, true, 100, 7.5, 123456, []byte("hello"))
+		e.Inject_lora_packet(5, 12, CR_LORA_4_8, false, 90, -5, 654321, []byte("world!"))
+		p, err = Lgw_receive(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != 2 {
+			t.Fatalf("got %d packets, want 2", len(p))
+		}
+		for i, w := range []struct {
+			if_chain byte
+			datarate uint32
+			coderate byte
+			status   byte
+			snr      float64
+			count_us uint32
+			payload  string
+		}{
+			{3, DR_LORA_SF7, CR_LORA_4_5, STAT_CRC_OK, 7.5, 123456, "hello"},
+			{5, DR_LORA_SF12, CR_LORA_4_8, STAT_CRC_BAD, -5, 654321, "world!"},
+		} {
+			if p[i].If_chain != w.if_chain || p[i].Modulation != MOD_LORA || p[i].Datarate != w.datarate ||
+				p[i].Coderate != w.coderate || p[i].Status != w.status || p[i].Snr != w.snr {
+				t.Errorf("packet %d: %+v", i, p[i])
+			}
+			/* the timestamp is corrected for the demodulation delay, less than a millisecond at 125 kHz */
+			if (p[i].Count_us > w.count_us) || (w.count_us-p[i].Count_us > 1000) {
+				t.Errorf("packet %d: timestamp %d, injected %d", i, p[i].Count_us, w.count_us)
+			}
+			if int(p[i].Size) != len(w.payload) || !bytes.Equal(p[i].Payload[:p[i].Size], []byte(w.payload)) {
+				t.Errorf("packet %d: payload %q, want %q", i, p[i].Payload[:p[i].Size], w.payload)
+			}
+		}
+		p, err = Lgw_receive(c)
+		if err != nil || len(p) != 0 {
+			t.Errorf("FIFO not emptied, %d packets, %v", len(p), err)
+		}
+	})
+}
+
+/* a register dump on a running board leaves the packets waiting in the RX FIFO intact */
+func TestDumpReceive(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, e := emulated(t, with_fpga)
+		e.Inject_lora_packet(0, 7, CR_LORA_4_5, true, 100, 7.5, 123456, []byte("hello"))
+		regs, err := Lgw_reg_dump(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regs[LGW_RX_DATA_BUF_DATA].Skipped || regs[LGW_VERSION].Skipped {
+			t.Errorf("data port %+v, version %+v", regs[LGW_RX_DATA_BUF_DATA], regs[LGW_VERSION])
+		}
+		if with_fpga {
+			_, err = Lgw_fpga_reg_dump(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		p, err := Lgw_receive(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != 1 || !bytes.Equal(p[0].Payload[:p[0].Size], []byte("hello")) {
+			t.Errorf("received %d packets after a dump: %+v", len(p), p)
+		}
+	})
+}
+
+func TestSend(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, e := emulated(t, with_fpga)
+		payload := []byte{0xCA, 0xFE, 0x01, 0x02}
+		err := Lgw_send(c, lora_tx(IMMEDIATE, 0, payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(e.Tx_buffer(), payload) {
+			t.Errorf("TX buffer % X, want it to end with % X", e.Tx_buffer(), payload)
+		}
+		st, err := Lgw_status(c, TX_STATUS)
+		if err != nil || st != TX_EMITTING {
+			t.Errorf("TX status %d, %v, want %d", st, err, TX_EMITTING)
+		}
+
+		err = Lgw_send(c, lora_tx(TIMESTAMPED, 5000000, payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err = Lgw_status(c, TX_STATUS)
+		if err != nil || st != TX_SCHEDULED {
+			t.Errorf("TX status %d, %v, want %d", st, err, TX_SCHEDULED)
+		}
+
+		Lgw_stop(c)
+		err = Lgw_send(c, lora_tx(IMMEDIATE, 0, payload))
+		if err == nil {
+			t.Error("send on a stopped concentrator succeeded")
+		}
+	})
+}
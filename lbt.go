@@ -0,0 +1,229 @@
+package liblorago
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	LBT_TIMESTAMP_MASK = 0x007FF000 /* 11-bits timestamp */
+	LBT_SCAN_TIME_FAST = 128        /* channel scan time in us */
+	LBT_SCAN_TIME_SLOW = 5000
+)
+
+/**
+@struct lgw_conf_lbt_chan_s
+@brief Configuration structure for LBT channels
+*/
+type lgw_conf_lbt_chan_s struct {
+	freq_hz      uint32
+	scan_time_us uint16
+}
+
+/* lbt_setup configures the SX127x and the FPGA for LBT, it gives up as soon as ctx is done, with the error of ctx */
+func lbt_setup(ctx context.Context, c *Concentrator) error {
+	s := c.state
+
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return fmt.Errorf("ERROR: LBT REQUIRES AN FPGA\n")
+	}
+
+	/* Check if LBT feature is supported by FPGA */
+	val, err := Lgw_fpga_reg_r(c, LGW_FPGA_FEATURE)
+	if err != nil {
+		return err
+	}
+	if TAKE_N_BITS_FROM(byte(val), 2, 1) == 0 {
+		return fmt.Errorf("ERROR: No support for LBT in FPGA\n")
+	}
+
+	/* Get FPGA lowest frequency for LBT channels */
+	val, err = Lgw_fpga_reg_r(c, LGW_FPGA_LBT_INITIAL_FREQ)
+	if err != nil {
+		return err
+	}
+	switch val {
+	case 0:
+		c.lbt_start_freq = 915000000
+	case 1:
+		c.lbt_start_freq = 863000000
+	default:
+		return fmt.Errorf("ERROR: LBT start frequency %d is not supported\n", val)
+	}
+
+	/* Configure SX127x for FSK */
+	err = setup_sx127x(ctx, c, c.lbt_start_freq, MOD_FSK, LGW_SX127X_RXBW_100K_HZ, s.lbt_rssi_offset, LGW_RADIO_TYPE_NONE) /* 200KHz LBT channels */
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("ERROR: Failed to configure SX127x for LBT\n")
+	}
+
+	/* Configure FPGA for LBT */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_RSSI_TARGET, -2*int32(s.lbt_rssi_target)) /* Convert RSSI target in dBm to FPGA register format */
+	if err != nil {
+		return err
+	}
+
+	/* Set default values for non-active LBT channels */
+	channels := s.lbt_channel_cfg
+	for i := int(s.lbt_nb_active_channel); i < LBT_CHANNEL_FREQ_NB; i++ {
+		channels[i].freq_hz = c.lbt_start_freq
+		channels[i].scan_time_us = LBT_SCAN_TIME_FAST /* fastest scan for non-active channels */
+	}
+
+	/* Configure FPGA for both active and non-active LBT channels */
+	for i, ch := range channels {
+		if ch.freq_hz < c.lbt_start_freq {
+			return fmt.Errorf("ERROR: LBT channel frequency is out of range (%d)\n", ch.freq_hz)
+		}
+		if (ch.scan_time_us != LBT_SCAN_TIME_FAST) && (ch.scan_time_us != LBT_SCAN_TIME_SLOW) {
+			return fmt.Errorf("ERROR: LBT channel scan time is not supported (%d)\n", ch.scan_time_us)
+		}
+		freq_offset := (ch.freq_hz - c.lbt_start_freq) / 100E3 /* 100kHz unit */
+		err = Lgw_fpga_reg_w(c, LGW_FPGA_LBT_CH0_FREQ_OFFSET+Lgw_fpga_reg_id(i), int32(freq_offset))
+		if err != nil {
+			return err
+		}
+		if ch.scan_time_us == LBT_SCAN_TIME_SLOW { /* configured to 128 by default */
+			err = Lgw_fpga_reg_w(c, LGW_FPGA_LBT_SCAN_TIME_CH0+Lgw_fpga_reg_id(i), 1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Note: LBT configuration:\n")
+	fmt.Printf("\tlbt_enable: %t\n", s.lbt_enable)
+	fmt.Printf("\tlbt_nb_active_channel: %d\n", s.lbt_nb_active_channel)
+	fmt.Printf("\tlbt_start_freq: %d\n", c.lbt_start_freq)
+	fmt.Printf("\tlbt_rssi_target: %d\n", s.lbt_rssi_target)
+	for i, ch := range channels {
+		fmt.Printf("\tlbt_channel_cfg[%d].freq_hz: %d\n", i, ch.freq_hz)
+		fmt.Printf("\tlbt_channel_cfg[%d].scan_time_us: %d\n", i, ch.scan_time_us)
+	}
+
+	return nil
+}
+
+func lbt_start(c *Concentrator) error {
+	err := Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_FEATURE_START, 1)
+	if err != nil {
+		return fmt.Errorf("ERROR: Failed to start LBT FSM\n")
+	}
+	return nil
+}
+
+/*
+lbt_is_channel_free tells if a transmission on freq_hz with bandwidth bw starting at count_us and lasting duration_us can go.
+A 125 kHz TX must match an LBT channel, a 250 kHz TX must sit in between two consecutive 200 kHz spaced channels,
+other bandwidths are rejected. The selected channels must have been free for long enough before the end of the transmission.
+*/
+func lbt_is_channel_free(c *Concentrator, freq_hz uint32, bw byte, count_us uint32, duration_us uint32) (bool, error) {
+	s := c.state
+
+	/* Always allow if LBT is disabled */
+	if !s.lbt_enable {
+		return true, nil
+	}
+
+	tx_start_time := count_us & LBT_TIMESTAMP_MASK
+
+	/* Select LBT Channel corresponding to required TX frequency */
+	lbt_channel_decod_1 := -1
+	lbt_channel_decod_2 := -1
+	var tx_max_time uint32
+	switch bw {
+	case BW_125KHZ:
+		for i := 0; i < int(s.lbt_nb_active_channel); i++ {
+			ch := s.lbt_channel_cfg[i]
+			if is_equal_freq(freq_hz, ch.freq_hz) {
+				lbt_channel_decod_1 = i
+				lbt_channel_decod_2 = i
+				if ch.scan_time_us == LBT_SCAN_TIME_SLOW {
+					tx_max_time = 4000000 /* 4 seconds */
+				} else {
+					tx_max_time = 400000 /* 400 milliseconds */
+				}
+				break
+			}
+		}
+	case BW_250KHZ:
+		/* In case of 250KHz, the TX freq has to be in between 2 consecutive channels of 200KHz BW. */
+		/* The TX can only be over 2 channels, not more */
+		for i := 0; i+1 < int(s.lbt_nb_active_channel); i++ {
+			ch := s.lbt_channel_cfg[i]
+			next := s.lbt_channel_cfg[i+1]
+			if is_equal_freq(freq_hz, (ch.freq_hz+next.freq_hz)/2) && (next.freq_hz-ch.freq_hz == 200E3) {
+				lbt_channel_decod_1 = i
+				lbt_channel_decod_2 = i + 1
+				if ch.scan_time_us == LBT_SCAN_TIME_SLOW {
+					tx_max_time = 4000000 /* 4 seconds */
+				} else {
+					tx_max_time = 200000 /* 200 milliseconds */
+				}
+				break
+			}
+		}
+	default:
+		fmt.Printf("ERROR: TX request rejected (LBT), bandwidth 0x%02X not supported\n", bw)
+		return false, nil
+	}
+
+	/* Get last time when selected channel was free */
+	lbt_time := uint32(0)
+	if (lbt_channel_decod_1 >= 0) && (lbt_channel_decod_2 >= 0) {
+		err := Lgw_fpga_reg_w(c, LGW_FPGA_LBT_TIMESTAMP_SELECT_CH, int32(lbt_channel_decod_1))
+		if err != nil {
+			return false, err
+		}
+		val, err := Lgw_fpga_reg_r(c, LGW_FPGA_LBT_TIMESTAMP_CH)
+		if err != nil {
+			return false, err
+		}
+		lbt_time = uint32(val&0x0000FFFF) * 256 /* 16bits (1LSB = 256µs) */
+
+		if lbt_channel_decod_1 != lbt_channel_decod_2 {
+			err = Lgw_fpga_reg_w(c, LGW_FPGA_LBT_TIMESTAMP_SELECT_CH, int32(lbt_channel_decod_2))
+			if err != nil {
+				return false, err
+			}
+			val, err = Lgw_fpga_reg_r(c, LGW_FPGA_LBT_TIMESTAMP_CH)
+			if err != nil {
+				return false, err
+			}
+			lbt_time2 := uint32(val&0x0000FFFF) * 256
+			if lbt_time2 < lbt_time {
+				lbt_time = lbt_time2
+			}
+		}
+	}
+
+	tx_end_time := (tx_start_time + duration_us) & LBT_TIMESTAMP_MASK
+	var delta_time uint32
+	if lbt_time < tx_end_time {
+		delta_time = tx_end_time - lbt_time
+	} else {
+		/* It means LBT counter has wrapped */
+		fmt.Printf("LBT: lbt counter has wrapped\n")
+		delta_time = (LBT_TIMESTAMP_MASK - lbt_time) + tx_end_time
+	}
+
+	/* lbt_time: last time when channel was free */
+	/* tx_max_time: maximum time allowed to send packet since last free time */
+	/* 2048: some margin */
+	if (delta_time < (tx_max_time - 2048)) && (lbt_time != 0) {
+		return true, nil
+	}
+	fmt.Printf("ERROR: TX request rejected (LBT)\n")
+	return false, nil
+}
+
+func is_equal_freq(a, b uint32) bool {
+	diff := int64(a) - int64(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= 10000 /* 10kHz acceptance */
+}
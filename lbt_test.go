@@ -0,0 +1,93 @@
+package liblorago
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+/* channels of testdata/global_conf_lbt.json: 922.2 MHz (fast scan) and 922.4 MHz (slow scan) */
+func TestLbtBandwidth(t *testing.T) {
+	s, err := ParseConfig("testdata/global_conf_lbt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEmulator(true)
+	c := NewConcentratorTransport(e, s)
+	/* no full start, it waits 8.4 s for the LBT scan to settle */
+	err = Lgw_connect(c, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Lgw_disconnect(c)
+	err = lbt_setup(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = lbt_start(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		freq_hz uint32
+		bw      byte
+		busy    int /* LBT channel to mark busy, -1 for none */
+		allowed bool
+	}{
+		{"125 kHz on a channel", 922200000, BW_125KHZ, -1, true},
+		{"125 kHz on a busy channel", 922200000, BW_125KHZ, 0, false},
+		{"125 kHz in between two channels", 922300000, BW_125KHZ, -1, false},
+		{"250 kHz in between two channels", 922300000, BW_250KHZ, -1, true},
+		{"250 kHz with the lower channel busy", 922300000, BW_250KHZ, 0, false},
+		{"250 kHz with the upper channel busy", 922300000, BW_250KHZ, 1, false},
+		{"250 kHz on a channel", 922200000, BW_250KHZ, -1, false},
+		{"500 kHz in between two channels", 922300000, BW_500KHZ, -1, false},
+		{"500 kHz on a channel", 922200000, BW_500KHZ, -1, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.busy >= 0 {
+				/* busy for longer than a fast scan channel allows, a free channel reports it is free now */
+				e.Set_lbt_busy(tc.busy, true)
+				defer e.Set_lbt_busy(tc.busy, false)
+				time.Sleep(450 * time.Millisecond)
+			}
+			now, err := Lgw_get_instcnt(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			allowed, err := lbt_is_channel_free(c, tc.freq_hz, tc.bw, now+10000, 50000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != tc.allowed {
+				t.Errorf("TX allowed %v, want %v", allowed, tc.allowed)
+			}
+		})
+	}
+}
+
+/* the SX127x setup sleeps 1.3 s, a cancelled context cuts it short */
+func TestLbtSetupCancel(t *testing.T) {
+	s, err := ParseConfig("testdata/global_conf_lbt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConcentratorTransport(NewEmulator(true), s)
+	err = Lgw_connect(c, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Lgw_disconnect(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	err = lbt_setup(ctx, c)
+	if err != context.DeadlineExceeded {
+		t.Errorf("cancelled LBT setup returned %v", err)
+	}
+	if time.Since(begin) > 500*time.Millisecond {
+		t.Errorf("cancelled LBT setup took %v", time.Since(begin))
+	}
+}
@@ -1,8 +1,8 @@
 package liblorago
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"time"
 )
 
@@ -25,26 +25,26 @@ var SX125x_XOSC_DISABLE = 2     /* Disable of Xtal Oscillator blocks bit0:regula
 var SX125x_32MHz_FRAC = uint32(15625)
 var PLL_LOCK_MAX_ATTEMPTS = 5
 
-func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf_clkout byte, rf_enable bool, rf_radio_type lgw_radio_type_e, freq_hz uint32) error {
+func Lgw_setup_sx125x(c *Concentrator, rf_chain, rf_clkout byte, rf_enable bool, rf_radio_type lgw_radio_type_e, freq_hz uint32) error {
 	if rf_chain >= LGW_RF_CHAIN_NB {
 		return fmt.Errorf("ERROR: INVALID RF_CHAIN\n")
 	}
 
 	/* Get version to identify SX1255/57 silicon revision */
-	b, err := Sx125x_read(c, lgw_spi_mux_mode, spi_mux_target, rf_chain, 0x07)
+	b, err := Sx125x_read(c, rf_chain, 0x07)
 	if err != nil {
 		return err
 	}
-	fmt.Print("Note: SX125x #%d version register returned 0x%02X\n", rf_chain, b)
+	fmt.Printf("Note: SX125x #%d version register returned 0x%02X\n", rf_chain, b)
 
 	/* General radio setup */
 	if rf_clkout == rf_chain {
-		err := Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x10, uint8(SX125x_TX_DAC_CLK_SEL+2))
+		err := Sx125x_write(c, rf_chain, 0x10, uint8(SX125x_TX_DAC_CLK_SEL+2))
 		if err != nil {
 			return err
 		}
 	} else {
-		err := Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x10, uint8(SX125x_TX_DAC_CLK_SEL))
+		err := Sx125x_write(c, rf_chain, 0x10, uint8(SX125x_TX_DAC_CLK_SEL))
 		if err != nil {
 			return err
 		}
@@ -52,12 +52,12 @@ func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf
 
 	switch rf_radio_type {
 	case LGW_RADIO_TYPE_SX1255:
-		err := Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x28, uint8(SX125x_XOSC_GM_STARTUP+SX125x_XOSC_DISABLE*16))
+		err := Sx125x_write(c, rf_chain, 0x28, uint8(SX125x_XOSC_GM_STARTUP+SX125x_XOSC_DISABLE*16))
 		if err != nil {
 			return err
 		}
 	case LGW_RADIO_TYPE_SX1257:
-		err := Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x26, uint8(SX125x_XOSC_GM_STARTUP+SX125x_XOSC_DISABLE*16))
+		err := Sx125x_write(c, rf_chain, 0x26, uint8(SX125x_XOSC_GM_STARTUP+SX125x_XOSC_DISABLE*16))
 		if err != nil {
 			return err
 		}
@@ -65,29 +65,29 @@ func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf
 		return fmt.Errorf("ERROR: UNEXPECTED VALUE %d FOR RADIO TYPE\n", rf_radio_type)
 	}
 
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x08, uint8(SX125x_TX_MIX_GAIN+SX125x_TX_DAC_GAIN*16))
+	err = Sx125x_write(c, rf_chain, 0x08, uint8(SX125x_TX_MIX_GAIN+SX125x_TX_DAC_GAIN*16))
 	if err != nil {
 		return err
 	}
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x0A, uint8(SX125x_TX_ANA_BW+SX125x_TX_PLL_BW*32))
+	err = Sx125x_write(c, rf_chain, 0x0A, uint8(SX125x_TX_ANA_BW+SX125x_TX_PLL_BW*32))
 	if err != nil {
 		return err
 	}
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x0B, uint8(SX125x_TX_DAC_BW))
+	err = Sx125x_write(c, rf_chain, 0x0B, uint8(SX125x_TX_DAC_BW))
 	if err != nil {
 		return err
 	}
 
 	/* Rx gain and trim */
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x0C, uint8(SX125x_LNA_ZIN+SX125x_RX_BB_GAIN*2+SX125x_RX_LNA_GAIN*32))
+	err = Sx125x_write(c, rf_chain, 0x0C, uint8(SX125x_LNA_ZIN+SX125x_RX_BB_GAIN*2+SX125x_RX_LNA_GAIN*32))
 	if err != nil {
 		return err
 	}
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x0D, uint8(SX125x_RX_BB_BW+SX125x_RX_ADC_TRIM*4+SX125x_RX_ADC_BW*32))
+	err = Sx125x_write(c, rf_chain, 0x0D, uint8(SX125x_RX_BB_BW+SX125x_RX_ADC_TRIM*4+SX125x_RX_ADC_BW*32))
 	if err != nil {
 		return err
 	}
-	err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x0E, uint8(SX125x_ADC_TEMP+SX125x_RX_PLL_BW*2))
+	err = Sx125x_write(c, rf_chain, 0x0E, uint8(SX125x_ADC_TEMP+SX125x_RX_PLL_BW*2))
 	if err != nil {
 		return err
 	}
@@ -98,30 +98,30 @@ func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf
 		part_int := freq_hz / (SX125x_32MHz_FRAC << 7)                               /* integer part, gives the MSB */
 		part_frac := ((freq_hz % (SX125x_32MHz_FRAC << 7)) << 9) / SX125x_32MHz_FRAC /* fractional part, gives middle part and LSB */
 
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x01, 0xFF&uint8(part_int)) /* Most Significant Byte */
+		err = Sx125x_write(c, rf_chain, 0x01, 0xFF&uint8(part_int)) /* Most Significant Byte */
 		if err != nil {
 			return err
 		}
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x02, 0xFF&uint8(part_frac>>8)) /* middle byte */
+		err = Sx125x_write(c, rf_chain, 0x02, 0xFF&uint8(part_frac>>8)) /* middle byte */
 		if err != nil {
 			return err
 		}
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x03, 0xFF&uint8(part_frac)) /* Least Significant Byte */
+		err = Sx125x_write(c, rf_chain, 0x03, 0xFF&uint8(part_frac)) /* Least Significant Byte */
 		if err != nil {
 			return err
 		}
 	case LGW_RADIO_TYPE_SX1257:
-		part_int := freq_hz / (SX125x_32MHz_FRAC << 8)                                                /* integer part, gives the MSB */
-		part_frac := ((freq_hz % (SX125x_32MHz_FRAC << 8)) << 8) / SX125x_32MHz_FRAC                  /* fractional part, gives middle part and LSB */
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x01, 0xFF&uint8(part_int)) /* Most Significant Byte */
+		part_int := freq_hz / (SX125x_32MHz_FRAC << 8)                               /* integer part, gives the MSB */
+		part_frac := ((freq_hz % (SX125x_32MHz_FRAC << 8)) << 8) / SX125x_32MHz_FRAC /* fractional part, gives middle part and LSB */
+		err = Sx125x_write(c, rf_chain, 0x01, 0xFF&uint8(part_int))                  /* Most Significant Byte */
 		if err != nil {
 			return err
 		}
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x02, 0xFF&uint8(part_frac>>8)) /* middle byte */
+		err = Sx125x_write(c, rf_chain, 0x02, 0xFF&uint8(part_frac>>8)) /* middle byte */
 		if err != nil {
 			return err
 		}
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x03, 0xFF&uint8(part_frac)) /* Least Significant Byte */
+		err = Sx125x_write(c, rf_chain, 0x03, 0xFF&uint8(part_frac)) /* Least Significant Byte */
 		if err != nil {
 			return err
 		}
@@ -133,16 +133,16 @@ func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf
 		if cpt_attempts >= PLL_LOCK_MAX_ATTEMPTS {
 			return fmt.Errorf("ERROR: FAIL TO LOCK PLL\n")
 		}
-		err := Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x00, 1) /* enable Xtal oscillator */
+		err := Sx125x_write(c, rf_chain, 0x00, 1) /* enable Xtal oscillator */
 		if err != nil {
 			return err
 		}
-		err = Sx125x_write(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x00, 3) /* Enable RX (PLL+FE) */
+		err = Sx125x_write(c, rf_chain, 0x00, 3) /* Enable RX (PLL+FE) */
 		if err != nil {
 			return err
 		}
 		time.Sleep(1 * time.Millisecond)
-		val, err := Sx125x_read(c, rf_chain, lgw_spi_mux_mode, spi_mux_target, 0x11)
+		val, err := Sx125x_read(c, rf_chain, 0x11)
 		if err != nil {
 			return err
 		}
@@ -154,8 +154,8 @@ func Lgw_setup_sx125x(c *os.File, lgw_spi_mux_mode, spi_mux_target, rf_chain, rf
 	return nil
 }
 
-func Sx125x_write(c *os.File, channel, spi_mux_mode, spi_mux_target byte, addr, data uint8) error {
-	var reg_add, reg_dat, reg_cs uint16
+func Sx125x_write(c *Concentrator, channel, addr, data byte) error {
+	var reg_add, reg_dat, reg_cs Lgw_reg_id
 
 	/* checking input parameters */
 	if channel >= LGW_RF_CHAIN_NB {
@@ -184,23 +184,24 @@ func Sx125x_write(c *os.File, channel, spi_mux_mode, spi_mux_target byte, addr,
 	}
 
 	/* SPI master data write procedure */
-	err := Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 0)
+	/* not batched: the gaps between the writes may matter to the radio SPI master, batching them is not validated on a board */
+	err := Lgw_reg_w(c, reg_cs, 0)
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_add, int32(0x80|addr)) /* MSB at 1 for write operation */
+	err = Lgw_reg_w(c, reg_add, int32(0x80|addr)) /* MSB at 1 for write operation */
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_dat, int32(data))
+	err = Lgw_reg_w(c, reg_dat, int32(data))
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 1)
+	err = Lgw_reg_w(c, reg_cs, 1)
 	if err != nil {
 		return err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 0)
+	err = Lgw_reg_w(c, reg_cs, 0)
 	if err != nil {
 		return err
 	}
@@ -208,8 +209,8 @@ func Sx125x_write(c *os.File, channel, spi_mux_mode, spi_mux_target byte, addr,
 	return nil
 }
 
-func Sx125x_read(c *os.File, spi_mux_mode, spi_mux_target byte, channel, addr byte) (byte, error) {
-	var reg_add, reg_dat, reg_cs, reg_rb uint16
+func Sx125x_read(c *Concentrator, channel, addr byte) (byte, error) {
+	var reg_add, reg_dat, reg_cs, reg_rb Lgw_reg_id
 
 	/* checking input parameters */
 	if channel >= LGW_RF_CHAIN_NB {
@@ -240,30 +241,333 @@ func Sx125x_read(c *os.File, spi_mux_mode, spi_mux_target byte, channel, addr by
 	}
 
 	/* SPI master data read procedure */
-	err := Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 0)
+	err := Lgw_reg_w(c, reg_cs, 0)
 	if err != nil {
 		return 0, err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_add, int32(addr)) /* MSB at 0 for read operation */
+	err = Lgw_reg_w(c, reg_add, int32(addr)) /* MSB at 0 for read operation */
 	if err != nil {
 		return 0, err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_dat, 0)
+	err = Lgw_reg_w(c, reg_dat, 0)
 	if err != nil {
 		return 0, err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 1)
+	err = Lgw_reg_w(c, reg_cs, 1)
 	if err != nil {
 		return 0, err
 	}
-	err = Lgw_reg_w(c, spi_mux_mode, spi_mux_target, reg_cs, 0)
+	err = Lgw_reg_w(c, reg_cs, 0)
 	if err != nil {
 		return 0, err
 	}
-	read_value, err := Lgw_reg_r(c, spi_mux_mode, spi_mux_target, reg_rb)
+	read_value, err := Lgw_reg_r(c, reg_rb)
 	if err != nil {
 		return 0, err
 	}
 
 	return byte(read_value), nil
 }
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* SX127x auxiliary radio, behind the FPGA SPI mux (LBT and spectral scan) */
+
+const (
+	SX127X_REG_OPMODE      = 0x01
+	SX127X_REG_BITRATEMSB  = 0x02
+	SX127X_REG_BITRATELSB  = 0x03
+	SX127X_REG_FDEVMSB     = 0x04
+	SX127X_REG_FDEVLSB     = 0x05
+	SX127X_REG_FRFMSB      = 0x06
+	SX127X_REG_FRFMID      = 0x07
+	SX127X_REG_FRFLSB      = 0x08
+	SX127X_REG_LNA         = 0x0C
+	SX127X_REG_RXCONFIG    = 0x0D
+	SX127X_REG_RSSICONFIG  = 0x0E
+	SX127X_REG_RSSIVALUE   = 0x11
+	SX127X_REG_RXBW        = 0x12
+	SX127X_REG_RXDELAY     = 0x23
+	SX127X_REG_IRQFLAGS1   = 0x3E
+	SX127X_REG_VERSION     = 0x42
+	SX1272_REG_PLLHOP      = 0x4B
+	SX1272_REG_PLL         = 0x5C
+	SX1276_REG_PLLHOP      = 0x44
+	SX1276_REG_PLL         = 0x70
+	SX1272_VERSION         = 0x22
+	SX1276_VERSION         = 0x12
+	SX127X_MODE_SLEEP      = 0
+	SX127X_MODE_STANDBY    = 1
+	SX127X_MODE_FSTX       = 2
+	SX127X_MODE_TX         = 3
+	SX127X_MODE_FSRX       = 4
+	SX127X_MODE_RX         = 5
+	SX127X_XTAL_FREQ       = 32000000
+	SX127X_FSK_BITRATE_MSB = 125 /* BR and FDEV for 200 kHz bandwidth */
+	SX127X_FSK_BITRATE_LSB = 0
+	SX127X_FSK_FDEV_MSB    = 2
+	SX127X_FSK_FDEV_LSB    = 225
+)
+
+type lgw_sx127x_rxbw_e byte
+
+const (
+	LGW_SX127X_RXBW_2K6_HZ lgw_sx127x_rxbw_e = iota
+	LGW_SX127X_RXBW_3K1_HZ
+	LGW_SX127X_RXBW_3K9_HZ
+	LGW_SX127X_RXBW_5K2_HZ
+	LGW_SX127X_RXBW_6K3_HZ
+	LGW_SX127X_RXBW_7K8_HZ
+	LGW_SX127X_RXBW_10K4_HZ
+	LGW_SX127X_RXBW_12K5_HZ
+	LGW_SX127X_RXBW_15K6_HZ
+	LGW_SX127X_RXBW_20K8_HZ
+	LGW_SX127X_RXBW_25K_HZ
+	LGW_SX127X_RXBW_31K3_HZ
+	LGW_SX127X_RXBW_41K7_HZ
+	LGW_SX127X_RXBW_50K_HZ
+	LGW_SX127X_RXBW_62K5_HZ
+	LGW_SX127X_RXBW_83K3_HZ
+	LGW_SX127X_RXBW_100K_HZ
+	LGW_SX127X_RXBW_125K_HZ
+	LGW_SX127X_RXBW_166K7_HZ
+	LGW_SX127X_RXBW_200K_HZ
+	LGW_SX127X_RXBW_250K_HZ
+)
+
+type lgw_sx127x_FSK_bandwidth_s struct {
+	RxBwKHz  uint32
+	RxBwMant byte
+	RxBwExp  byte
+}
+
+var sx127x_FskBandwidths = [...]lgw_sx127x_FSK_bandwidth_s{
+	{2600, 2, 7}, /* LGW_SX127X_RXBW_2K6_HZ */
+	{3100, 1, 7}, /* LGW_SX127X_RXBW_3K1_HZ */
+	{3900, 0, 7}, /* ... */
+	{5200, 2, 6},
+	{6300, 1, 6},
+	{7800, 0, 6},
+	{10400, 2, 5},
+	{12500, 1, 5},
+	{15600, 0, 5},
+	{20800, 2, 4},
+	{25000, 1, 4}, /* ... */
+	{31300, 0, 4},
+	{41700, 2, 3},
+	{50000, 1, 3},
+	{62500, 0, 3},
+	{83333, 2, 2},
+	{100000, 1, 2},
+	{125000, 0, 2},
+	{166700, 2, 1},
+	{200000, 1, 1}, /* ... */
+	{250000, 0, 1}, /* LGW_SX127X_RXBW_250K_HZ */
+}
+
+func Lgw_sx127x_reg_w(c *Concentrator, address, reg_value byte) error {
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return fmt.Errorf("ERROR: NO FPGA, SX127X NOT REACHABLE\n")
+	}
+	return c.transport.Spi_w(LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_SX127X, address, reg_value)
+}
+
+func Lgw_sx127x_reg_r(c *Concentrator, address byte) (byte, error) {
+	if c.transport == nil {
+		return 0, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return 0, fmt.Errorf("ERROR: NO FPGA, SX127X NOT REACHABLE\n")
+	}
+	return c.transport.Spi_r(LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_SX127X, address)
+}
+
+/* the reset line polarity differs between SX1272 and SX1276 */
+func Lgw_sx127x_reset(c *Concentrator, radio_type lgw_radio_type_e) error {
+	var first, second int32
+	switch radio_type {
+	case LGW_RADIO_TYPE_SX1276:
+		first, second = 0, 1
+	case LGW_RADIO_TYPE_SX1272:
+		first, second = 1, 0
+	default:
+		return fmt.Errorf("ERROR: UNEXPECTED VALUE %d FOR RADIO TYPE\n", radio_type)
+	}
+	err := Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_RADIO_RESET, first)
+	if err != nil {
+		return err
+	}
+	return Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_RADIO_RESET, second)
+}
+
+/* Lgw_sx127x_detect resets the radio the way each type expects and checks its version register */
+func Lgw_sx127x_detect(c *Concentrator) (lgw_radio_type_e, error) {
+	supported_radio_type := []struct {
+		radio_type  lgw_radio_type_e
+		reg_version byte
+	}{
+		{LGW_RADIO_TYPE_SX1272, SX1272_VERSION},
+		{LGW_RADIO_TYPE_SX1276, SX1276_VERSION},
+	}
+	for _, t := range supported_radio_type {
+		err := Lgw_sx127x_reset(c, t.radio_type)
+		if err != nil {
+			return LGW_RADIO_TYPE_NONE, err
+		}
+		version, err := Lgw_sx127x_reg_r(c, SX127X_REG_VERSION)
+		if err != nil {
+			return LGW_RADIO_TYPE_NONE, err
+		}
+		if version == t.reg_version {
+			return t.radio_type, nil
+		}
+		fmt.Printf("INFO: detect radio type %d failed (read: 0x%02X, expected 0x%02X)\n", t.radio_type, version, t.reg_version)
+	}
+	return LGW_RADIO_TYPE_NONE, fmt.Errorf("ERROR: sx127x radio has not been found\n")
+}
+
+func Sx127x_set_mode(c *Concentrator, mode byte) error {
+	return Lgw_sx127x_reg_w(c, SX127X_REG_OPMODE, mode&0x07) /* FSK, no modulation shaping */
+}
+
+func Sx127x_set_freq(c *Concentrator, freq_hz uint32) error {
+	freq_reg := (uint64(freq_hz) << 19) / SX127X_XTAL_FREQ
+	err := Lgw_sx127x_reg_w(c, SX127X_REG_FRFMSB, byte(freq_reg>>16))
+	if err != nil {
+		return err
+	}
+	err = Lgw_sx127x_reg_w(c, SX127X_REG_FRFMID, byte(freq_reg>>8))
+	if err != nil {
+		return err
+	}
+	return Lgw_sx127x_reg_w(c, SX127X_REG_FRFLSB, byte(freq_reg))
+}
+
+/* Sx127x_rssi returns the instantaneous RSSI in dBm, offset by the RSSI offset given at setup */
+func Sx127x_rssi(c *Concentrator) (float64, error) {
+	v, err := Lgw_sx127x_reg_r(c, SX127X_REG_RSSIVALUE)
+	if err != nil {
+		return 0, err
+	}
+	return -float64(v) / 2, nil
+}
+
+/* Lgw_setup_sx127x puts the SX127x in FSK RX, radio_type LGW_RADIO_TYPE_NONE accepts whichever is detected */
+func Lgw_setup_sx127x(c *Concentrator, freq_hz uint32, modulation byte, rxbw_khz lgw_sx127x_rxbw_e, rssi_offset int8, radio_type lgw_radio_type_e) error {
+	return setup_sx127x(context.Background(), c, freq_hz, modulation, rxbw_khz, rssi_offset, radio_type)
+}
+
+/* setup_sx127x is Lgw_setup_sx127x giving up as soon as ctx is done, with the error of ctx */
+func setup_sx127x(ctx context.Context, c *Concentrator, freq_hz uint32, modulation byte, rxbw_khz lgw_sx127x_rxbw_e, rssi_offset int8, radio_type lgw_radio_type_e) error {
+	/* check parameters */
+	if modulation != MOD_FSK {
+		return fmt.Errorf("ERROR: modulation not supported for SX127x (%d)\n", modulation)
+	}
+	if (radio_type != LGW_RADIO_TYPE_NONE) && (radio_type != LGW_RADIO_TYPE_SX1272) && (radio_type != LGW_RADIO_TYPE_SX1276) {
+		return fmt.Errorf("ERROR: radio type not supported for SX127x (%d)\n", radio_type)
+	}
+	if int(rxbw_khz) >= len(sx127x_FskBandwidths) {
+		return fmt.Errorf("ERROR: RX bandwidth not supported for SX127x (%d)\n", rxbw_khz)
+	}
+
+	detected, err := Lgw_sx127x_detect(c)
+	if err != nil {
+		return err
+	}
+	if (radio_type != LGW_RADIO_TYPE_NONE) && (detected != radio_type) {
+		return fmt.Errorf("ERROR: sx127x radio type mismatch (found %d, expected %d)\n", detected, radio_type)
+	}
+	fmt.Printf("INFO: sx127x radio type %d detected\n", detected)
+
+	return setup_sx127x_FSK(ctx, c, freq_hz, rxbw_khz, rssi_offset, detected)
+}
+
+func setup_sx127x_FSK(ctx context.Context, c *Concentrator, freq_hz uint32, rxbw_khz lgw_sx127x_rxbw_e, rssi_offset int8, radio_type lgw_radio_type_e) error {
+	var reg_pllhop, reg_adcbw, reg_adctrim, reg_pll, reg_pllstartup byte
+	if radio_type == LGW_RADIO_TYPE_SX1272 {
+		reg_pllhop, reg_adcbw, reg_adctrim, reg_pll, reg_pllstartup = SX1272_REG_PLLHOP, 0x68, 0x69, SX1272_REG_PLL, 0x47
+	} else {
+		reg_pllhop, reg_adcbw, reg_adctrim, reg_pll, reg_pllstartup = SX1276_REG_PLLHOP, 0x57, 0x58, SX1276_REG_PLL, 0x43
+	}
+	PllHop := byte(1)
+	LnaGain := byte(1)
+	LnaBoost := byte(3)
+	AdcBwAuto := byte(0)
+	AdcBw := byte(7)
+	AdcLowPwr := byte(0)
+	AdcTrim := byte(6)
+	AdcTest := byte(0)
+	RxBwExp := sx127x_FskBandwidths[rxbw_khz].RxBwExp
+	RxBwMant := sx127x_FskBandwidths[rxbw_khz].RxBwMant
+	RssiSmoothing := byte(5)
+	RssiOffsetReg := byte(rssi_offset) & 0x1F /* 2's complement on 5 bits */
+
+	/* set in FSK mode, sleep then standby */
+	for _, mode := range []byte{SX127X_MODE_SLEEP, SX127X_MODE_SLEEP, SX127X_MODE_STANDBY} {
+		err := Sx127x_set_mode(c, mode)
+		if err != nil {
+			return err
+		}
+		err = sleep_ctx(ctx, 100*time.Millisecond)
+		if err != nil {
+			return err
+		}
+	}
+
+	/* set RF carrier frequency */
+	err := Lgw_sx127x_reg_w(c, reg_pllhop, PllHop<<7)
+	if err != nil {
+		return err
+	}
+	err = Sx127x_set_freq(c, freq_hz)
+	if err != nil {
+		return err
+	}
+
+	config := []struct {
+		addr byte
+		val  byte
+	}{
+		{SX127X_REG_LNA, LnaBoost | (LnaGain << 5)}, /* improved sensitivity, highest gain */
+		{reg_adcbw, AdcBw | (AdcBwAuto << 3)},
+		{reg_adctrim, AdcTest | (AdcTrim << 4) | (AdcLowPwr << 7)},
+		{SX127X_REG_BITRATEMSB, SX127X_FSK_BITRATE_MSB},
+		{SX127X_REG_BITRATELSB, SX127X_FSK_BITRATE_LSB},
+		{SX127X_REG_FDEVMSB, SX127X_FSK_FDEV_MSB},
+		{SX127X_REG_FDEVLSB, SX127X_FSK_FDEV_LSB},
+		{SX127X_REG_RXCONFIG, 0},                                      /* disable AGC */
+		{SX127X_REG_RSSICONFIG, RssiSmoothing | (RssiOffsetReg << 3)}, /* RSSI smoothing on 64 samples */
+		{SX127X_REG_RXBW, RxBwExp | (RxBwMant << 3)},
+		{SX127X_REG_RXDELAY, 2},
+		{reg_pll, 0x10},     /* PLL BW set to 75 KHz */
+		{reg_pllstartup, 1}, /* optimize PLL start-up time */
+	}
+	for _, r := range config {
+		err = Lgw_sx127x_reg_w(c, r.addr, r.val)
+		if err != nil {
+			return fmt.Errorf("ERROR: Failed to configure SX127x\n")
+		}
+	}
+
+	/* set RX continuous mode */
+	err = Sx127x_set_mode(c, SX127X_MODE_RX)
+	if err != nil {
+		return err
+	}
+	err = sleep_ctx(ctx, 500*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	reg_val, err := Lgw_sx127x_reg_r(c, SX127X_REG_IRQFLAGS1)
+	if err != nil {
+		return err
+	}
+	/* check if RxReady and ModeReady */
+	if (TAKE_N_BITS_FROM(reg_val, 6, 1) == 0) || (TAKE_N_BITS_FROM(reg_val, 7, 1) == 0) {
+		return fmt.Errorf("ERROR: SX127x failed to enter RX continuous mode\n")
+	}
+	return sleep_ctx(ctx, 500*time.Millisecond)
+}
@@ -0,0 +1,314 @@
+package liblorago
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+SPI session files have one transfer per line:
+
+	<us since start> <w|r|wb|rb> <mux mode> <mux target> <address> <data>[ ! <error>]
+
+address and data are hex, data is what was written for w/wb and what was read for r/rb,
+"-" stands for no data. Lines starting with # are comments.
+*/
+
+const (
+	SPI_OP_W  = "w"
+	SPI_OP_R  = "r"
+	SPI_OP_WB = "wb"
+	SPI_OP_RB = "rb"
+)
+
+/* Recorder is a Transport that logs every transfer of the Transport it wraps */
+type Recorder struct {
+	lock  sync.Mutex
+	t     Transport
+	w     io.Writer
+	file  *os.File /* set when the recorder owns the session file */
+	start time.Time
+}
+
+func NewRecorder(t Transport, w io.Writer) *Recorder {
+	r := &Recorder{t: t, w: w, start: time.Now()}
+	fmt.Fprintf(w, "# liblorago SPI session started %s\n", r.start.Format(time.RFC3339Nano))
+	return r
+}
+
+/* NewRecorderFile records into a new file at path, the file is closed with the recorder */
+func NewRecorderFile(t Transport, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(t, f)
+	r.file = f
+	return r, nil
+}
+
+func (r *Recorder) log(op string, spi_mux_mode, spi_mux_target, address byte, data []byte, err error) {
+	d := "-"
+	if len(data) > 0 {
+		d = hex.EncodeToString(data)
+	}
+	line := fmt.Sprintf("%d %s %d %d %02x %s", time.Since(r.start)/time.Microsecond, op, spi_mux_mode, spi_mux_target, address, d)
+	if err != nil {
+		line += " ! " + strings.Replace(strings.TrimSpace(err.Error()), "\n", " ", -1)
+	}
+	fmt.Fprintln(r.w, line)
+}
+
+func (r *Recorder) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	err := r.t.Spi_w(spi_mux_mode, spi_mux_target, address, data)
+	r.log(SPI_OP_W, spi_mux_mode, spi_mux_target, address, []byte{data}, err)
+	return err
+}
+
+func (r *Recorder) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	data, err := r.t.Spi_r(spi_mux_mode, spi_mux_target, address)
+	r.log(SPI_OP_R, spi_mux_mode, spi_mux_target, address, []byte{data}, err)
+	return data, err
+}
+
+func (r *Recorder) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	err := r.t.Spi_wb(spi_mux_mode, spi_mux_target, address, data)
+	r.log(SPI_OP_WB, spi_mux_mode, spi_mux_target, address, data, err)
+	return err
+}
+
+func (r *Recorder) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	data, err := r.t.Spi_rb(spi_mux_mode, spi_mux_target, address, size)
+	if err != nil {
+		/* keep the requested size so the replayer can check it */
+		r.log(SPI_OP_RB, spi_mux_mode, spi_mux_target, address, make([]byte, size), err)
+	} else {
+		r.log(SPI_OP_RB, spi_mux_mode, spi_mux_target, address, data, err)
+	}
+	return data, err
+}
+
+/*
+Spi_batch logs each transfer of the batch as the single transfer it stands for, so sessions replay the same.
+A failed batch is logged as its first transfer with the error, what went through before the failure is unknown
+and the read buffers hold nothing meaningful; replayed, the batch fails on that first transfer.
+*/
+func (r *Recorder) Spi_batch(xfers []Spi_transfer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	err := spi_batch(r.t, xfers)
+	if (err != nil) && (len(xfers) > 0) {
+		xfers = xfers[:1]
+	}
+	for _, x := range xfers {
+		read := x.Read
+		if (err != nil) && (read != nil) {
+			/* keep the requested size so the replayer can check it */
+			read = make([]byte, len(read))
+		}
+		switch {
+		case len(x.Read) == 1:
+			r.log(SPI_OP_R, x.Mode, x.Target, x.Address, read, err)
+		case x.Read != nil:
+			r.log(SPI_OP_RB, x.Mode, x.Target, x.Address, read, err)
+		case len(x.Data) == 1:
+			r.log(SPI_OP_W, x.Mode, x.Target, x.Address, x.Data, err)
+		default:
+			r.log(SPI_OP_WB, x.Mode, x.Target, x.Address, x.Data, err)
+		}
+	}
+	return err
+}
+
+/* Close closes the wrapped Transport, and the session file if the recorder created it */
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	err := r.t.Close()
+	if r.file != nil {
+		ferr := r.file.Close()
+		r.file = nil
+		if err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+type spi_op struct {
+	line           int
+	op             string
+	spi_mux_mode   byte
+	spi_mux_target byte
+	address        byte
+	data           []byte
+	err            error
+}
+
+/* Replayer is a Transport that serves a recorded session back, in order */
+type Replayer struct {
+	lock sync.Mutex
+	ops  []spi_op
+	pos  int
+}
+
+func NewReplayer(rd io.Reader) (*Replayer, error) {
+	p := &Replayer{}
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		o := spi_op{line: line}
+		if i := strings.Index(text, " ! "); i >= 0 {
+			o.err = errors.New(text[i+3:])
+			text = text[:i]
+		}
+		f := strings.Fields(text)
+		if len(f) != 6 {
+			return nil, fmt.Errorf("ERROR: MALFORMED SPI SESSION LINE %d\n", line)
+		}
+		o.op = f[1]
+		switch o.op {
+		case SPI_OP_W, SPI_OP_R, SPI_OP_WB, SPI_OP_RB:
+		default:
+			return nil, fmt.Errorf("ERROR: UNKNOWN SPI OPERATION %q LINE %d\n", o.op, line)
+		}
+		mode, err1 := strconv.ParseUint(f[2], 10, 8)
+		target, err2 := strconv.ParseUint(f[3], 10, 8)
+		addr, err3 := strconv.ParseUint(f[4], 16, 8)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("ERROR: MALFORMED SPI SESSION LINE %d\n", line)
+		}
+		o.spi_mux_mode = byte(mode)
+		o.spi_mux_target = byte(target)
+		o.address = byte(addr)
+		if f[5] != "-" {
+			d, err := hex.DecodeString(f[5])
+			if err != nil {
+				return nil, fmt.Errorf("ERROR: MALFORMED SPI SESSION DATA LINE %d\n", line)
+			}
+			o.data = d
+		}
+		p.ops = append(p.ops, o)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewReplayerFile(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayer(f)
+}
+
+/* Remaining returns the number of recorded transfers not replayed yet */
+func (p *Replayer) Remaining() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.ops) - p.pos
+}
+
+/* next returns the next recorded transfer, checking that it is the one the HAL asks for */
+func (p *Replayer) next(op string, spi_mux_mode, spi_mux_target, address byte) (spi_op, error) {
+	if p.pos >= len(p.ops) {
+		return spi_op{}, fmt.Errorf("ERROR: END OF SPI SESSION, UNEXPECTED %s %d %d %02x\n", op, spi_mux_mode, spi_mux_target, address)
+	}
+	o := p.ops[p.pos]
+	if o.op != op || o.spi_mux_mode != spi_mux_mode || o.spi_mux_target != spi_mux_target || o.address != address {
+		return o, fmt.Errorf("ERROR: SPI SESSION MISMATCH LINE %d, RECORDED %s %d %d %02x, GOT %s %d %d %02x\n", o.line, o.op, o.spi_mux_mode, o.spi_mux_target, o.address, op, spi_mux_mode, spi_mux_target, address)
+	}
+	p.pos++
+	return o, nil
+}
+
+func (p *Replayer) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	o, err := p.next(SPI_OP_W, spi_mux_mode, spi_mux_target, address)
+	if err != nil {
+		return err
+	}
+	if len(o.data) != 1 || o.data[0] != data {
+		return fmt.Errorf("ERROR: SPI SESSION MISMATCH LINE %d, RECORDED DATA %x, GOT %02x\n", o.line, o.data, data)
+	}
+	return o.err
+}
+
+func (p *Replayer) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	o, err := p.next(SPI_OP_R, spi_mux_mode, spi_mux_target, address)
+	if err != nil {
+		return 0, err
+	}
+	if o.err != nil {
+		return 0, o.err
+	}
+	if len(o.data) != 1 {
+		return 0, fmt.Errorf("ERROR: MALFORMED SPI SESSION DATA LINE %d\n", o.line)
+	}
+	return o.data[0], nil
+}
+
+func (p *Replayer) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	o, err := p.next(SPI_OP_WB, spi_mux_mode, spi_mux_target, address)
+	if err != nil {
+		return err
+	}
+	if string(o.data) != string(data) {
+		return fmt.Errorf("ERROR: SPI SESSION MISMATCH LINE %d, RECORDED %d BYTES, GOT %d DIFFERENT BYTES\n", o.line, len(o.data), len(data))
+	}
+	return o.err
+}
+
+func (p *Replayer) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	o, err := p.next(SPI_OP_RB, spi_mux_mode, spi_mux_target, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.data) != int(size) {
+		return nil, fmt.Errorf("ERROR: SPI SESSION MISMATCH LINE %d, RECORDED %d BYTES, GOT REQUEST FOR %d\n", o.line, len(o.data), size)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	data := make([]byte, size)
+	copy(data, o.data)
+	return data, nil
+}
+
+func (p *Replayer) Close() error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package liblorago
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* failing_batcher is an emulator whose batches fail, as a spidev whose SPI_IOC_MESSAGE is refused */
+type failing_batcher struct {
+	*Emulator
+}
+
+func (f failing_batcher) Spi_batch(xfers []Spi_transfer) error {
+	for i := range xfers {
+		for j := range xfers[i].Read {
+			xfers[i].Read[j] = 0xEE /* garbage left in the buffers */
+		}
+	}
+	return fmt.Errorf("ERROR: SPI BATCH FAILED\n")
+}
+
+func TestRecorderBatchError(t *testing.T) {
+	var session bytes.Buffer
+	r := NewRecorder(failing_batcher{NewEmulator(false)}, &session)
+	xfers := []Spi_transfer{
+		{Mode: LGW_SPI_MUX_MODE0, Target: LGW_SPI_MUX_TARGET_SX1301, Address: 0x01, Read: make([]byte, 4)},
+		{Mode: LGW_SPI_MUX_MODE0, Target: LGW_SPI_MUX_TARGET_SX1301, Address: 0x02, Data: []byte{0x55}},
+		{Mode: LGW_SPI_MUX_MODE0, Target: LGW_SPI_MUX_TARGET_SX1301, Address: 0x03, Read: make([]byte, 1)},
+	}
+	err := r.Spi_batch(xfers)
+	if err == nil {
+		t.Fatal("batch error not returned")
+	}
+	lines := strings.Split(strings.TrimSpace(session.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("failed batch logged as %d lines:\n%s", len(lines)-1, session.String())
+	}
+	if !strings.Contains(lines[1], " rb 0 0 01 00000000 ! ") {
+		t.Errorf("failed batch logged as %q", lines[1])
+	}
+
+	/* replayed through the single transfers, the batch fails on its first transfer */
+	p, err := NewReplayer(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spi_batch(p, xfers)
+	if err == nil || !strings.Contains(err.Error(), "SPI BATCH FAILED") {
+		t.Errorf("replayed batch returned %v", err)
+	}
+	if p.Remaining() != 0 {
+		t.Errorf("%d transfers left in the session", p.Remaining())
+	}
+}
+
+/* Connect then Start, and a restart after Stop, go on recording in the file of a recorder given by the caller */
+func TestRecorderConnectStart(t *testing.T) {
+	s, err := ParseConfig("testdata/global_conf.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "session.txt")
+	r, err := NewRecorderFile(NewEmulator(false), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	c := NewConcentratorTransport(r, s)
+	size := func() int64 {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	err = c.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected := size()
+	err = c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := size()
+	if stopped <= connected {
+		t.Fatalf("start not recorded, %d bytes after Connect, %d after Start and Stop", connected, stopped)
+	}
+	err = c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size() <= stopped {
+		t.Errorf("restart not recorded")
+	}
+}
@@ -0,0 +1,184 @@
+package liblorago
+
+import (
+	"fmt"
+)
+
+type reg_batch_key struct {
+	page int8 /* -1 for the registers common to all pages */
+	addr uint8
+}
+
+/*
+RegBatch queues SX1301 register accesses and sends them in one SPI transaction on Commit.
+Page switches are queued along, and the bytes written or read by the batch are remembered so that
+the read-modify-write of a register sharing its byte with others only reads the board once.
+A batch dropped without Commit sends nothing and leaves the page cache as it was.
+*/
+type RegBatch struct {
+	c      *Concentrator
+	xfers  []Spi_transfer
+	shadow map[reg_batch_key]byte
+	page   int8 /* page selected once the queued accesses are done, -1 when unknown */
+}
+
+func NewRegBatch(c *Concentrator) *RegBatch {
+	return &RegBatch{
+		c:      c,
+		shadow: make(map[reg_batch_key]byte),
+		page:   c.page,
+	}
+}
+
+func (b *RegBatch) queue(address byte, data, read []byte) {
+	b.xfers = append(b.xfers, Spi_transfer{
+		Mode:    b.c.spi_mux_mode,
+		Target:  LGW_SPI_MUX_TARGET_SX1301,
+		Address: address,
+		Data:    data,
+		Read:    read,
+	})
+}
+
+/* page_switch queues the page selection, the page cache follows on Commit */
+func (b *RegBatch) page_switch(page int8) {
+	if page == -1 {
+		return
+	}
+	lgw_regpage := PAGE_MASK & byte(page)
+	if !b.c.mcu_reg_ctrl && (b.page == int8(lgw_regpage)) {
+		return
+	}
+	b.queue(PAGE_ADDR, []byte{lgw_regpage}, nil)
+	b.page = int8(lgw_regpage)
+}
+
+/* Reg_w queues the write of a register, PAGE_REG, SOFT_RESET and EMERGENCY_FORCE_HOST_CTRL commit the batch and go through Lgw_reg_w */
+func (b *RegBatch) Reg_w(register_id Lgw_reg_id, reg_value int32) error {
+	if register_id >= LGW_TOTALREGS {
+		return fmt.Errorf("ERROR: REGISTER NUMBER OUT OF DEFINED RANGE\n")
+	}
+	if (register_id == LGW_PAGE_REG) || (register_id == LGW_SOFT_RESET) || (register_id == LGW_EMERGENCY_FORCE_HOST_CTRL) {
+		err := b.Commit()
+		if err != nil {
+			return err
+		}
+		b.shadow = make(map[reg_batch_key]byte)
+		err = Lgw_reg_w(b.c, register_id, reg_value)
+		b.page = b.c.page
+		return err
+	}
+
+	r := loregs[register_id]
+	if r.rdon == 1 {
+		return fmt.Errorf("ERROR: TRYING TO WRITE A READ-ONLY REGISTER\n")
+	}
+	if (r.leng == 0) || (r.leng > 32) {
+		return fmt.Errorf("ERROR: REGISTER SIZE IS NOT SUPPORTED\n")
+	}
+	b.page_switch(r.page)
+
+	size_byte := (uint16(r.offs) + uint16(r.leng) + 7) / 8
+	buf := make([]byte, size_byte)
+	if (r.offs != 0) || (r.leng < 8) || ((r.leng%8 != 0) && reg_tail_shared(loregs[:], r)) {
+		/* read-modify-write, from the bytes known to the batch or from the board, as reg_w_align32 */
+		old, err := b.current(r, buf)
+		if err != nil {
+			return err
+		}
+		mask := ((uint64(1) << r.leng) - 1) << r.offs
+		u := (old &^ mask) | ((uint64(uint32(reg_value)) << r.offs) & mask) /* mixing old & new data */
+		for i := range buf {
+			buf[i] = byte(u)
+			u = u >> 8
+		}
+	} else {
+		/* direct write, least significant byte first */
+		for i := range buf {
+			buf[i] = byte(reg_value)
+			reg_value = reg_value >> 8
+		}
+	}
+
+	b.queue(r.addr, buf, nil)
+	for i := range buf {
+		b.shadow[reg_batch_key{r.page, r.addr + uint8(i)}] = buf[i]
+	}
+	return nil
+}
+
+/* current returns the bytes of r as they will be once the queued accesses are done, it reads them along with the queued accesses if the batch doesn't know them */
+func (b *RegBatch) current(r Lgw_reg_s, buf []byte) (uint64, error) {
+	known := true
+	for i := range buf {
+		v, ok := b.shadow[reg_batch_key{r.page, r.addr + uint8(i)}]
+		if !ok {
+			known = false
+			break
+		}
+		buf[i] = v
+	}
+	if !known {
+		/* the page of r is the last one queued */
+		b.queue(r.addr, nil, buf)
+		err := b.Commit()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return reg_bytes_to_u64(buf), nil
+}
+
+/* Reg_wb queues a burst write, data must not be modified before Commit */
+func (b *RegBatch) Reg_wb(register_id Lgw_reg_id, data []byte) error {
+	if register_id >= LGW_TOTALREGS {
+		return fmt.Errorf("ERROR: REGISTER NUMBER OUT OF DEFINED RANGE\n")
+	}
+	r := loregs[register_id]
+	if r.rdon == 1 {
+		return fmt.Errorf("ERROR: TRYING TO BURST WRITE A READ-ONLY REGISTER\n")
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("ERROR: BURST OF NULL LENGTH\n")
+	}
+	b.page_switch(r.page)
+	b.queue(r.addr, data, nil)
+	/* a burst may target a data port, what it leaves in the registers is unknown */
+	for i := range data {
+		delete(b.shadow, reg_batch_key{r.page, r.addr + uint8(i)})
+	}
+	return nil
+}
+
+/* Reg_rb queues a burst read, the returned buffer is filled by Commit */
+func (b *RegBatch) Reg_rb(register_id Lgw_reg_id, size uint16) ([]byte, error) {
+	if register_id >= LGW_TOTALREGS {
+		return nil, fmt.Errorf("ERROR: REGISTER NUMBER OUT OF DEFINED RANGE\n")
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("ERROR: BURST OF NULL LENGTH\n")
+	}
+	r := loregs[register_id]
+	b.page_switch(r.page)
+	read := make([]byte, size)
+	b.queue(r.addr, nil, read)
+	return read, nil
+}
+
+/* Commit sends the queued accesses, the batch can be used again afterwards */
+func (b *RegBatch) Commit() error {
+	if len(b.xfers) == 0 {
+		return nil
+	}
+	if b.c.transport == nil {
+		b.xfers = b.xfers[:0]
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	err := spi_batch(b.c.transport, b.xfers)
+	b.xfers = b.xfers[:0]
+	if err != nil {
+		b.page = -1
+	}
+	b.c.page = b.page
+	return err
+}
@@ -3,691 +3,702 @@ package liblorago
 import (
 	"fmt"
 	"log"
-	"os"
+	"strings"
+	"time"
 )
 
 const (
 	PAGE_ADDR = 0x00
 	PAGE_MASK = 0x03
+
+	BIST_TIMEOUT = time.Second /* time given to a BIST engine to test the RAMs */
+	BIST_POLL    = time.Millisecond
 )
 
 var FPGA_VERSION []byte = []byte{31, 33} /* several versions could be supported */
 
 type Lgw_reg_s struct {
-	page int8  /*!< page containing the register (-1 for all pages) */
-	addr uint8 /*!< base address of the register (7 bit) */
-	offs uint8 /*!< position of the register LSB (between 0 to 7) */
-	sign uint8 //bool  /*!< 1 indicates the register is signed (2 complem.) */
-	leng uint8 /*!< number of bits in the register */
-	rdon uint8 //bool  /*!< 1 indicates a read-only register */
-	dflt int32 /*!< register default value */
+	page int8   /*!< page containing the register (-1 for all pages) */
+	addr uint8  /*!< base address of the register (7 bit) */
+	offs uint8  /*!< position of the register LSB (between 0 to 7) */
+	sign uint8  //bool  /*!< 1 indicates the register is signed (2 complem.) */
+	leng uint8  /*!< number of bits in the register */
+	rdon uint8  //bool  /*!< 1 indicates a read-only register */
+	dflt int32  /*!< register default value */
+	name string /*!< name of the register ID constant */
 }
 
 var loregs = [...]Lgw_reg_s{
-	{-1, 0, 0, 0, 2, 0, 0},     /* PAGE_REG */
-	{-1, 0, 7, 0, 1, 0, 0},     /* SOFT_RESET */
-	{-1, 1, 0, 0, 8, 1, 103},   /* VERSION */
-	{-1, 2, 0, 0, 16, 0, 0},    /* RX_DATA_BUF_ADDR */
-	{-1, 4, 0, 0, 8, 0, 0},     /* RX_DATA_BUF_DATA */
-	{-1, 5, 0, 0, 8, 0, 0},     /* TX_DATA_BUF_ADDR */
-	{-1, 6, 0, 0, 8, 0, 0},     /* TX_DATA_BUF_DATA */
-	{-1, 7, 0, 0, 8, 0, 0},     /* CAPTURE_RAM_ADDR */
-	{-1, 8, 0, 0, 8, 1, 0},     /* CAPTURE_RAM_DATA */
-	{-1, 9, 0, 0, 8, 0, 0},     /* MCU_PROM_ADDR */
-	{-1, 10, 0, 0, 8, 0, 0},    /* MCU_PROM_DATA */
-	{-1, 11, 0, 0, 8, 0, 0},    /* RX_PACKET_DATA_FIFO_NUM_STORED */
-	{-1, 12, 0, 0, 16, 1, 0},   /* RX_PACKET_DATA_FIFO_ADDR_POINTER */
-	{-1, 14, 0, 0, 8, 1, 0},    /* RX_PACKET_DATA_FIFO_STATUS */
-	{-1, 15, 0, 0, 8, 1, 0},    /* RX_PACKET_DATA_FIFO_PAYLOAD_SIZE */
-	{-1, 16, 0, 0, 1, 0, 0},    /* MBWSSF_MODEM_ENABLE */
-	{-1, 16, 1, 0, 1, 0, 0},    /* CONCENTRATOR_MODEM_ENABLE */
-	{-1, 16, 2, 0, 1, 0, 0},    /* FSK_MODEM_ENABLE */
-	{-1, 16, 3, 0, 1, 0, 0},    /* GLOBAL_EN */
-	{-1, 17, 0, 0, 1, 0, 1},    /* CLK32M_EN */
-	{-1, 17, 1, 0, 1, 0, 1},    /* CLKHS_EN */
-	{-1, 18, 0, 0, 1, 0, 0},    /* START_BIST0 */
-	{-1, 18, 1, 0, 1, 0, 0},    /* START_BIST1 */
-	{-1, 18, 2, 0, 1, 0, 0},    /* CLEAR_BIST0 */
-	{-1, 18, 3, 0, 1, 0, 0},    /* CLEAR_BIST1 */
-	{-1, 19, 0, 0, 1, 1, 0},    /* BIST0_FINISHED */
-	{-1, 19, 1, 0, 1, 1, 0},    /* BIST1_FINISHED */
-	{-1, 20, 0, 0, 1, 1, 0},    /* MCU_AGC_PROG_RAM_BIST_STATUS */
-	{-1, 20, 1, 0, 1, 1, 0},    /* MCU_ARB_PROG_RAM_BIST_STATUS */
-	{-1, 20, 2, 0, 1, 1, 0},    /* CAPTURE_RAM_BIST_STATUS */
-	{-1, 20, 3, 0, 1, 1, 0},    /* CHAN_FIR_RAM0_BIST_STATUS */
-	{-1, 20, 4, 0, 1, 1, 0},    /* CHAN_FIR_RAM1_BIST_STATUS */
-	{-1, 21, 0, 0, 1, 1, 0},    /* CORR0_RAM_BIST_STATUS */
-	{-1, 21, 1, 0, 1, 1, 0},    /* CORR1_RAM_BIST_STATUS */
-	{-1, 21, 2, 0, 1, 1, 0},    /* CORR2_RAM_BIST_STATUS */
-	{-1, 21, 3, 0, 1, 1, 0},    /* CORR3_RAM_BIST_STATUS */
-	{-1, 21, 4, 0, 1, 1, 0},    /* CORR4_RAM_BIST_STATUS */
-	{-1, 21, 5, 0, 1, 1, 0},    /* CORR5_RAM_BIST_STATUS */
-	{-1, 21, 6, 0, 1, 1, 0},    /* CORR6_RAM_BIST_STATUS */
-	{-1, 21, 7, 0, 1, 1, 0},    /* CORR7_RAM_BIST_STATUS */
-	{-1, 22, 0, 0, 1, 1, 0},    /* MODEM0_RAM0_BIST_STATUS */
-	{-1, 22, 1, 0, 1, 1, 0},    /* MODEM1_RAM0_BIST_STATUS */
-	{-1, 22, 2, 0, 1, 1, 0},    /* MODEM2_RAM0_BIST_STATUS */
-	{-1, 22, 3, 0, 1, 1, 0},    /* MODEM3_RAM0_BIST_STATUS */
-	{-1, 22, 4, 0, 1, 1, 0},    /* MODEM4_RAM0_BIST_STATUS */
-	{-1, 22, 5, 0, 1, 1, 0},    /* MODEM5_RAM0_BIST_STATUS */
-	{-1, 22, 6, 0, 1, 1, 0},    /* MODEM6_RAM0_BIST_STATUS */
-	{-1, 22, 7, 0, 1, 1, 0},    /* MODEM7_RAM0_BIST_STATUS */
-	{-1, 23, 0, 0, 1, 1, 0},    /* MODEM0_RAM1_BIST_STATUS */
-	{-1, 23, 1, 0, 1, 1, 0},    /* MODEM1_RAM1_BIST_STATUS */
-	{-1, 23, 2, 0, 1, 1, 0},    /* MODEM2_RAM1_BIST_STATUS */
-	{-1, 23, 3, 0, 1, 1, 0},    /* MODEM3_RAM1_BIST_STATUS */
-	{-1, 23, 4, 0, 1, 1, 0},    /* MODEM4_RAM1_BIST_STATUS */
-	{-1, 23, 5, 0, 1, 1, 0},    /* MODEM5_RAM1_BIST_STATUS */
-	{-1, 23, 6, 0, 1, 1, 0},    /* MODEM6_RAM1_BIST_STATUS */
-	{-1, 23, 7, 0, 1, 1, 0},    /* MODEM7_RAM1_BIST_STATUS */
-	{-1, 24, 0, 0, 1, 1, 0},    /* MODEM0_RAM2_BIST_STATUS */
-	{-1, 24, 1, 0, 1, 1, 0},    /* MODEM1_RAM2_BIST_STATUS */
-	{-1, 24, 2, 0, 1, 1, 0},    /* MODEM2_RAM2_BIST_STATUS */
-	{-1, 24, 3, 0, 1, 1, 0},    /* MODEM3_RAM2_BIST_STATUS */
-	{-1, 24, 4, 0, 1, 1, 0},    /* MODEM4_RAM2_BIST_STATUS */
-	{-1, 24, 5, 0, 1, 1, 0},    /* MODEM5_RAM2_BIST_STATUS */
-	{-1, 24, 6, 0, 1, 1, 0},    /* MODEM6_RAM2_BIST_STATUS */
-	{-1, 24, 7, 0, 1, 1, 0},    /* MODEM7_RAM2_BIST_STATUS */
-	{-1, 25, 0, 0, 1, 1, 0},    /* MODEM_MBWSSF_RAM0_BIST_STATUS */
-	{-1, 25, 1, 0, 1, 1, 0},    /* MODEM_MBWSSF_RAM1_BIST_STATUS */
-	{-1, 25, 2, 0, 1, 1, 0},    /* MODEM_MBWSSF_RAM2_BIST_STATUS */
-	{-1, 26, 0, 0, 1, 1, 0},    /* MCU_AGC_DATA_RAM_BIST0_STATUS */
-	{-1, 26, 1, 0, 1, 1, 0},    /* MCU_AGC_DATA_RAM_BIST1_STATUS */
-	{-1, 26, 2, 0, 1, 1, 0},    /* MCU_ARB_DATA_RAM_BIST0_STATUS */
-	{-1, 26, 3, 0, 1, 1, 0},    /* MCU_ARB_DATA_RAM_BIST1_STATUS */
-	{-1, 26, 4, 0, 1, 1, 0},    /* TX_TOP_RAM_BIST0_STATUS */
-	{-1, 26, 5, 0, 1, 1, 0},    /* TX_TOP_RAM_BIST1_STATUS */
-	{-1, 26, 6, 0, 1, 1, 0},    /* DATA_MNGT_RAM_BIST0_STATUS */
-	{-1, 26, 7, 0, 1, 1, 0},    /* DATA_MNGT_RAM_BIST1_STATUS */
-	{-1, 27, 0, 0, 4, 0, 0},    /* GPIO_SELECT_INPUT */
-	{-1, 28, 0, 0, 4, 0, 0},    /* GPIO_SELECT_OUTPUT */
-	{-1, 29, 0, 0, 5, 0, 0},    /* GPIO_MODE */
-	{-1, 30, 0, 0, 5, 1, 0},    /* GPIO_PIN_REG_IN */
-	{-1, 31, 0, 0, 5, 0, 0},    /* GPIO_PIN_REG_OUT */
-	{-1, 32, 0, 0, 8, 1, 0},    /* MCU_AGC_STATUS */
-	{-1, 125, 0, 0, 8, 1, 0},   /* MCU_ARB_STATUS */
-	{-1, 126, 0, 0, 8, 1, 1},   /* CHIP_ID */
-	{-1, 127, 0, 0, 1, 0, 1},   /* EMERGENCY_FORCE_HOST_CTRL */
-	{0, 33, 0, 0, 1, 0, 0},     /* RX_INVERT_IQ */
-	{0, 33, 1, 0, 1, 0, 1},     /* MODEM_INVERT_IQ */
-	{0, 33, 2, 0, 1, 0, 0},     /* MBWSSF_MODEM_INVERT_IQ */
-	{0, 33, 3, 0, 1, 0, 0},     /* RX_EDGE_SELECT */
-	{0, 33, 4, 0, 1, 0, 0},     /* MISC_RADIO_EN */
-	{0, 33, 5, 0, 1, 0, 0},     /* FSK_MODEM_INVERT_IQ */
-	{0, 34, 0, 0, 4, 0, 7},     /* FILTER_GAIN */
-	{0, 35, 0, 0, 8, 0, 240},   /* RADIO_SELECT */
-	{0, 36, 0, 1, 13, 0, -384}, /* IF_FREQ_0 */
-	{0, 38, 0, 1, 13, 0, -128}, /* IF_FREQ_1 */
-	{0, 40, 0, 1, 13, 0, 128},  /* IF_FREQ_2 */
-	{0, 42, 0, 1, 13, 0, 384},  /* IF_FREQ_3 */
-	{0, 44, 0, 1, 13, 0, -384}, /* IF_FREQ_4 */
-	{0, 46, 0, 1, 13, 0, -128}, /* IF_FREQ_5 */
-	{0, 48, 0, 1, 13, 0, 128},  /* IF_FREQ_6 */
-	{0, 50, 0, 1, 13, 0, 384},  /* IF_FREQ_7 */
-	{0, 52, 0, 1, 13, 0, 0},    /* IF_FREQ_8 */
-	{0, 54, 0, 1, 13, 0, 0},    /* IF_FREQ_9 */
-	{0, 64, 0, 0, 1, 0, 0},     /* CHANN_OVERRIDE_AGC_GAIN */
-	{0, 64, 1, 0, 4, 0, 7},     /* CHANN_AGC_GAIN */
-	{0, 65, 0, 0, 7, 0, 0},     /* CORR0_DETECT_EN */
-	{0, 66, 0, 0, 7, 0, 0},     /* CORR1_DETECT_EN */
-	{0, 67, 0, 0, 7, 0, 0},     /* CORR2_DETECT_EN */
-	{0, 68, 0, 0, 7, 0, 0},     /* CORR3_DETECT_EN */
-	{0, 69, 0, 0, 7, 0, 0},     /* CORR4_DETECT_EN */
-	{0, 70, 0, 0, 7, 0, 0},     /* CORR5_DETECT_EN */
-	{0, 71, 0, 0, 7, 0, 0},     /* CORR6_DETECT_EN */
-	{0, 72, 0, 0, 7, 0, 0},     /* CORR7_DETECT_EN */
-	{0, 73, 0, 0, 1, 0, 0},     /* CORR_SAME_PEAKS_OPTION_SF6 */
-	{0, 73, 1, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF7 */
-	{0, 73, 2, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF8 */
-	{0, 73, 3, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF9 */
-	{0, 73, 4, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF10 */
-	{0, 73, 5, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF11 */
-	{0, 73, 6, 0, 1, 0, 1},     /* CORR_SAME_PEAKS_OPTION_SF12 */
-	{0, 74, 0, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF6 */
-	{0, 74, 4, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF7 */
-	{0, 75, 0, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF8 */
-	{0, 75, 4, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF9 */
-	{0, 76, 0, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF10 */
-	{0, 76, 4, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF11 */
-	{0, 77, 0, 0, 4, 0, 4},     /* CORR_SIG_NOISE_RATIO_SF12 */
-	{0, 78, 0, 0, 4, 0, 4},     /* CORR_NUM_SAME_PEAK */
-	{0, 78, 4, 0, 3, 0, 5},     /* CORR_MAC_GAIN */
-	{0, 81, 0, 0, 12, 0, 0},    /* ADJUST_MODEM_START_OFFSET_RDX4 */
-	{0, 83, 0, 0, 12, 0, 4092}, /* ADJUST_MODEM_START_OFFSET_SF12_RDX4 */
-	{0, 85, 0, 0, 8, 0, 7},     /* DBG_CORR_SELECT_SF */
-	{0, 86, 0, 0, 8, 0, 0},     /* DBG_CORR_SELECT_CHANNEL */
-	{0, 87, 0, 0, 8, 1, 0},     /* DBG_DETECT_CPT */
-	{0, 88, 0, 0, 8, 1, 0},     /* DBG_SYMB_CPT */
-	{0, 89, 0, 0, 1, 0, 1},     /* CHIRP_INVERT_RX */
-	{0, 89, 1, 0, 1, 0, 1},     /* DC_NOTCH_EN */
-	{0, 90, 0, 0, 1, 0, 0},     /* IMPLICIT_CRC_EN */
-	{0, 90, 1, 0, 3, 0, 0},     /* IMPLICIT_CODING_RATE */
-	{0, 91, 0, 0, 8, 0, 0},     /* IMPLICIT_PAYLOAD_LENGHT */
-	{0, 92, 0, 0, 8, 0, 29},    /* FREQ_TO_TIME_INVERT */
-	{0, 93, 0, 0, 6, 0, 9},     /* FREQ_TO_TIME_DRIFT */
-	{0, 94, 0, 0, 2, 0, 2},     /* PAYLOAD_FINE_TIMING_GAIN */
-	{0, 94, 2, 0, 2, 0, 1},     /* PREAMBLE_FINE_TIMING_GAIN */
-	{0, 94, 4, 0, 2, 0, 0},     /* TRACKING_INTEGRAL */
-	{0, 95, 0, 0, 4, 0, 1},     /* FRAME_SYNCH_PEAK1_POS */
-	{0, 95, 4, 0, 4, 0, 2},     /* FRAME_SYNCH_PEAK2_POS */
-	{0, 96, 0, 0, 16, 0, 10},   /* PREAMBLE_SYMB1_NB */
-	{0, 98, 0, 0, 1, 0, 1},     /* FRAME_SYNCH_GAIN */
-	{0, 98, 1, 0, 1, 0, 1},     /* SYNCH_DETECT_TH */
-	{0, 99, 0, 0, 4, 0, 8},     /* LLR_SCALE */
-	{0, 99, 4, 0, 2, 0, 2},     /* SNR_AVG_CST */
-	{0, 100, 0, 0, 7, 0, 0},    /* PPM_OFFSET */
-	{0, 101, 0, 0, 8, 0, 255},  /* MAX_PAYLOAD_LEN */
-	{0, 102, 0, 0, 1, 0, 1},    /* ONLY_CRC_EN */
-	{0, 103, 0, 0, 8, 0, 0},    /* ZERO_PAD */
-	{0, 104, 0, 0, 4, 0, 8},    /* DEC_GAIN_OFFSET */
-	{0, 104, 4, 0, 4, 0, 7},    /* CHAN_GAIN_OFFSET */
-	{0, 105, 1, 0, 1, 0, 1},    /* FORCE_HOST_RADIO_CTRL */
-	{0, 105, 2, 0, 1, 0, 1},    /* FORCE_HOST_FE_CTRL */
-	{0, 105, 3, 0, 1, 0, 1},    /* FORCE_DEC_FILTER_GAIN */
-	{0, 106, 0, 0, 1, 0, 1},    /* MCU_RST_0 */
-	{0, 106, 1, 0, 1, 0, 1},    /* MCU_RST_1 */
-	{0, 106, 2, 0, 1, 0, 0},    /* MCU_SELECT_MUX_0 */
-	{0, 106, 3, 0, 1, 0, 0},    /* MCU_SELECT_MUX_1 */
-	{0, 106, 4, 0, 1, 1, 0},    /* MCU_CORRUPTION_DETECTED_0 */
-	{0, 106, 5, 0, 1, 1, 0},    /* MCU_CORRUPTION_DETECTED_1 */
-	{0, 106, 6, 0, 1, 0, 0},    /* MCU_SELECT_EDGE_0 */
-	{0, 106, 7, 0, 1, 0, 0},    /* MCU_SELECT_EDGE_1 */
-	{0, 107, 0, 0, 8, 0, 1},    /* CHANN_SELECT_RSSI */
-	{0, 108, 0, 0, 8, 0, 32},   /* RSSI_BB_DEFAULT_VALUE */
-	{0, 109, 0, 0, 8, 0, 100},  /* RSSI_DEC_DEFAULT_VALUE */
-	{0, 110, 0, 0, 8, 0, 100},  /* RSSI_CHANN_DEFAULT_VALUE */
-	{0, 111, 0, 0, 5, 0, 7},    /* RSSI_BB_FILTER_ALPHA */
-	{0, 112, 0, 0, 5, 0, 5},    /* RSSI_DEC_FILTER_ALPHA */
-	{0, 113, 0, 0, 5, 0, 8},    /* RSSI_CHANN_FILTER_ALPHA */
-	{0, 114, 0, 0, 6, 0, 0},    /* IQ_MISMATCH_A_AMP_COEFF */
-	{0, 115, 0, 0, 6, 0, 0},    /* IQ_MISMATCH_A_PHI_COEFF */
-	{0, 116, 0, 0, 6, 0, 0},    /* IQ_MISMATCH_B_AMP_COEFF */
-	{0, 116, 6, 0, 1, 0, 0},    /* IQ_MISMATCH_B_SEL_I */
-	{0, 117, 0, 0, 6, 0, 0},    /* IQ_MISMATCH_B_PHI_COEFF */
-	{1, 33, 0, 0, 1, 0, 0},     /* TX_TRIG_IMMEDIATE */
-	{1, 33, 1, 0, 1, 0, 0},     /* TX_TRIG_DELAYED */
-	{1, 33, 2, 0, 1, 0, 0},     /* TX_TRIG_GPS */
-	{1, 34, 0, 0, 16, 0, 0},    /* TX_START_DELAY */
-	{1, 36, 0, 0, 4, 0, 1},     /* TX_FRAME_SYNCH_PEAK1_POS */
-	{1, 36, 4, 0, 4, 0, 2},     /* TX_FRAME_SYNCH_PEAK2_POS */
-	{1, 37, 0, 0, 3, 0, 0},     /* TX_RAMP_DURATION */
-	{1, 39, 0, 1, 8, 0, 0},     /* TX_OFFSET_I */
-	{1, 40, 0, 1, 8, 0, 0},     /* TX_OFFSET_Q */
-	{1, 41, 0, 0, 1, 0, 0},     /* TX_MODE */
-	{1, 41, 1, 0, 4, 0, 0},     /* TX_ZERO_PAD */
-	{1, 41, 5, 0, 1, 0, 0},     /* TX_EDGE_SELECT */
-	{1, 41, 6, 0, 1, 0, 0},     /* TX_EDGE_SELECT_TOP */
-	{1, 42, 0, 0, 2, 0, 0},     /* TX_GAIN */
-	{1, 42, 2, 0, 3, 0, 5},     /* TX_CHIRP_LOW_PASS */
-	{1, 42, 5, 0, 2, 0, 0},     /* TX_FCC_WIDEBAND */
-	{1, 42, 7, 0, 1, 0, 1},     /* TX_SWAP_IQ */
-	{1, 43, 0, 0, 1, 0, 0},     /* MBWSSF_IMPLICIT_HEADER */
-	{1, 43, 1, 0, 1, 0, 0},     /* MBWSSF_IMPLICIT_CRC_EN */
-	{1, 43, 2, 0, 3, 0, 0},     /* MBWSSF_IMPLICIT_CODING_RATE */
-	{1, 44, 0, 0, 8, 0, 0},     /* MBWSSF_IMPLICIT_PAYLOAD_LENGHT */
-	{1, 45, 0, 0, 1, 0, 1},     /* MBWSSF_AGC_FREEZE_ON_DETECT */
-	{1, 46, 0, 0, 4, 0, 1},     /* MBWSSF_FRAME_SYNCH_PEAK1_POS */
-	{1, 46, 4, 0, 4, 0, 2},     /* MBWSSF_FRAME_SYNCH_PEAK2_POS */
-	{1, 47, 0, 0, 16, 0, 10},   /* MBWSSF_PREAMBLE_SYMB1_NB */
-	{1, 49, 0, 0, 1, 0, 1},     /* MBWSSF_FRAME_SYNCH_GAIN */
-	{1, 49, 1, 0, 1, 0, 1},     /* MBWSSF_SYNCH_DETECT_TH */
-	{1, 50, 0, 0, 8, 0, 10},    /* MBWSSF_DETECT_MIN_SINGLE_PEAK */
-	{1, 51, 0, 0, 3, 0, 3},     /* MBWSSF_DETECT_TRIG_SAME_PEAK_NB */
-	{1, 52, 0, 0, 8, 0, 29},    /* MBWSSF_FREQ_TO_TIME_INVERT */
-	{1, 53, 0, 0, 6, 0, 36},    /* MBWSSF_FREQ_TO_TIME_DRIFT */
-	{1, 54, 0, 0, 12, 0, 0},    /* MBWSSF_PPM_CORRECTION */
-	{1, 56, 0, 0, 2, 0, 2},     /* MBWSSF_PAYLOAD_FINE_TIMING_GAIN */
-	{1, 56, 2, 0, 2, 0, 1},     /* MBWSSF_PREAMBLE_FINE_TIMING_GAIN */
-	{1, 56, 4, 0, 2, 0, 0},     /* MBWSSF_TRACKING_INTEGRAL */
-	{1, 57, 0, 0, 8, 0, 0},     /* MBWSSF_ZERO_PAD */
-	{1, 58, 0, 0, 2, 0, 0},     /* MBWSSF_MODEM_BW */
-	{1, 58, 2, 0, 1, 0, 0},     /* MBWSSF_RADIO_SELECT */
-	{1, 58, 3, 0, 1, 0, 1},     /* MBWSSF_RX_CHIRP_INVERT */
-	{1, 59, 0, 0, 4, 0, 8},     /* MBWSSF_LLR_SCALE */
-	{1, 59, 4, 0, 2, 0, 3},     /* MBWSSF_SNR_AVG_CST */
-	{1, 59, 6, 0, 1, 0, 0},     /* MBWSSF_PPM_OFFSET */
-	{1, 60, 0, 0, 4, 0, 7},     /* MBWSSF_RATE_SF */
-	{1, 60, 4, 0, 1, 0, 1},     /* MBWSSF_ONLY_CRC_EN */
-	{1, 61, 0, 0, 8, 0, 255},   /* MBWSSF_MAX_PAYLOAD_LEN */
-	{1, 62, 0, 0, 8, 1, 128},   /* TX_STATUS */
-	{1, 63, 0, 0, 3, 0, 0},     /* FSK_CH_BW_EXPO */
-	{1, 63, 3, 0, 3, 0, 0},     /* FSK_RSSI_LENGTH */
-	{1, 63, 6, 0, 1, 0, 0},     /* FSK_RX_INVERT */
-	{1, 63, 7, 0, 1, 0, 0},     /* FSK_PKT_MODE */
-	{1, 64, 0, 0, 3, 0, 0},     /* FSK_PSIZE */
-	{1, 64, 3, 0, 1, 0, 0},     /* FSK_CRC_EN */
-	{1, 64, 4, 0, 2, 0, 0},     /* FSK_DCFREE_ENC */
-	{1, 64, 6, 0, 1, 0, 0},     /* FSK_CRC_IBM */
-	{1, 65, 0, 0, 5, 0, 0},     /* FSK_ERROR_OSR_TOL */
-	{1, 65, 7, 0, 1, 0, 0},     /* FSK_RADIO_SELECT */
-	{1, 66, 0, 0, 16, 0, 0},    /* FSK_BR_RATIO */
-	{1, 68, 0, 0, 32, 0, 0},    /* FSK_REF_PATTERN_LSB */
-	{1, 72, 0, 0, 32, 0, 0},    /* FSK_REF_PATTERN_MSB */
-	{1, 76, 0, 0, 8, 0, 0},     /* FSK_PKT_LENGTH */
-	{1, 77, 0, 0, 1, 0, 1},     /* FSK_TX_GAUSSIAN_EN */
-	{1, 77, 1, 0, 2, 0, 0},     /* FSK_TX_GAUSSIAN_SELECT_BT */
-	{1, 77, 3, 0, 1, 0, 1},     /* FSK_TX_PATTERN_EN */
-	{1, 77, 4, 0, 1, 0, 0},     /* FSK_TX_PREAMBLE_SEQ */
-	{1, 77, 5, 0, 3, 0, 0},     /* FSK_TX_PSIZE */
-	{1, 80, 0, 0, 8, 0, 0},     /* FSK_NODE_ADRS */
-	{1, 81, 0, 0, 8, 0, 0},     /* FSK_BROADCAST */
-	{1, 82, 0, 0, 1, 0, 1},     /* FSK_AUTO_AFC_ON */
-	{1, 83, 0, 0, 10, 0, 0},    /* FSK_PATTERN_TIMEOUT_CFG */
-	{2, 33, 0, 0, 8, 0, 0},     /* SPI_RADIO_A__DATA */
-	{2, 34, 0, 0, 8, 1, 0},     /* SPI_RADIO_A__DATA_READBACK */
-	{2, 35, 0, 0, 8, 0, 0},     /* SPI_RADIO_A__ADDR */
-	{2, 37, 0, 0, 1, 0, 0},     /* SPI_RADIO_A__CS */
-	{2, 38, 0, 0, 8, 0, 0},     /* SPI_RADIO_B__DATA */
-	{2, 39, 0, 0, 8, 1, 0},     /* SPI_RADIO_B__DATA_READBACK */
-	{2, 40, 0, 0, 8, 0, 0},     /* SPI_RADIO_B__ADDR */
-	{2, 42, 0, 0, 1, 0, 0},     /* SPI_RADIO_B__CS */
-	{2, 43, 0, 0, 1, 0, 0},     /* RADIO_A_EN */
-	{2, 43, 1, 0, 1, 0, 0},     /* RADIO_B_EN */
-	{2, 43, 2, 0, 1, 0, 1},     /* RADIO_RST */
-	{2, 43, 3, 0, 1, 0, 0},     /* LNA_A_EN */
-	{2, 43, 4, 0, 1, 0, 0},     /* PA_A_EN */
-	{2, 43, 5, 0, 1, 0, 0},     /* LNA_B_EN */
-	{2, 43, 6, 0, 1, 0, 0},     /* PA_B_EN */
-	{2, 44, 0, 0, 2, 0, 0},     /* PA_GAIN */
-	{2, 45, 0, 0, 4, 0, 2},     /* LNA_A_CTRL_LUT */
-	{2, 45, 4, 0, 4, 0, 4},     /* PA_A_CTRL_LUT */
-	{2, 46, 0, 0, 4, 0, 2},     /* LNA_B_CTRL_LUT */
-	{2, 46, 4, 0, 4, 0, 4},     /* PA_B_CTRL_LUT */
-	{2, 47, 0, 0, 5, 0, 0},     /* CAPTURE_SOURCE */
-	{2, 47, 5, 0, 1, 0, 0},     /* CAPTURE_START */
-	{2, 47, 6, 0, 1, 0, 0},     /* CAPTURE_FORCE_TRIGGER */
-	{2, 47, 7, 0, 1, 0, 0},     /* CAPTURE_WRAP */
-	{2, 48, 0, 0, 16, 0, 0},    /* CAPTURE_PERIOD */
-	{2, 51, 0, 0, 8, 1, 0},     /* MODEM_STATUS */
-	{2, 52, 0, 0, 8, 1, 0},     /* VALID_HEADER_COUNTER_0 */
-	{2, 54, 0, 0, 8, 1, 0},     /* VALID_PACKET_COUNTER_0 */
-	{2, 56, 0, 0, 8, 1, 0},     /* VALID_HEADER_COUNTER_MBWSSF */
-	{2, 57, 0, 0, 8, 1, 0},     /* VALID_HEADER_COUNTER_FSK */
-	{2, 58, 0, 0, 8, 1, 0},     /* VALID_PACKET_COUNTER_MBWSSF */
-	{2, 59, 0, 0, 8, 1, 0},     /* VALID_PACKET_COUNTER_FSK */
-	{2, 60, 0, 0, 8, 1, 0},     /* CHANN_RSSI */
-	{2, 61, 0, 0, 8, 1, 0},     /* BB_RSSI */
-	{2, 62, 0, 0, 8, 1, 0},     /* DEC_RSSI */
-	{2, 63, 0, 0, 8, 1, 0},     /* DBG_MCU_DATA */
-	{2, 64, 0, 0, 8, 1, 0},     /* DBG_ARB_MCU_RAM_DATA */
-	{2, 65, 0, 0, 8, 1, 0},     /* DBG_AGC_MCU_RAM_DATA */
-	{2, 66, 0, 0, 16, 1, 0},    /* NEXT_PACKET_CNT */
-	{2, 68, 0, 0, 16, 1, 0},    /* ADDR_CAPTURE_COUNT */
-	{2, 70, 0, 0, 32, 1, 0},    /* TIMESTAMP */
-	{2, 74, 0, 0, 4, 1, 0},     /* DBG_CHANN0_GAIN */
-	{2, 74, 4, 0, 4, 1, 0},     /* DBG_CHANN1_GAIN */
-	{2, 75, 0, 0, 4, 1, 0},     /* DBG_CHANN2_GAIN */
-	{2, 75, 4, 0, 4, 1, 0},     /* DBG_CHANN3_GAIN */
-	{2, 76, 0, 0, 4, 1, 0},     /* DBG_CHANN4_GAIN */
-	{2, 76, 4, 0, 4, 1, 0},     /* DBG_CHANN5_GAIN */
-	{2, 77, 0, 0, 4, 1, 0},     /* DBG_CHANN6_GAIN */
-	{2, 77, 4, 0, 4, 1, 0},     /* DBG_CHANN7_GAIN */
-	{2, 78, 0, 0, 4, 1, 0},     /* DBG_DEC_FILT_GAIN */
-	{2, 79, 0, 0, 3, 1, 0},     /* SPI_DATA_FIFO_PTR */
-	{2, 79, 3, 0, 3, 1, 0},     /* PACKET_DATA_FIFO_PTR */
-	{2, 80, 0, 0, 8, 0, 0},     /* DBG_ARB_MCU_RAM_ADDR */
-	{2, 81, 0, 0, 8, 0, 0},     /* DBG_AGC_MCU_RAM_ADDR */
-	{2, 82, 0, 0, 1, 0, 0},     /* SPI_MASTER_CHIP_SELECT_POLARITY */
-	{2, 82, 1, 0, 1, 0, 0},     /* SPI_MASTER_CPOL */
-	{2, 82, 2, 0, 1, 0, 0},     /* SPI_MASTER_CPHA */
-	{2, 83, 0, 0, 1, 0, 0},     /* SIG_GEN_ANALYSER_MUX_SEL */
-	{2, 84, 0, 0, 1, 0, 0},     /* SIG_GEN_EN */
-	{2, 84, 1, 0, 1, 0, 0},     /* SIG_ANALYSER_EN */
-	{2, 84, 2, 0, 2, 0, 0},     /* SIG_ANALYSER_AVG_LEN */
-	{2, 84, 4, 0, 3, 0, 0},     /* SIG_ANALYSER_PRECISION */
-	{2, 84, 7, 0, 1, 1, 0},     /* SIG_ANALYSER_VALID_OUT */
-	{2, 85, 0, 0, 8, 0, 0},     /* SIG_GEN_FREQ */
-	{2, 86, 0, 0, 8, 0, 0},     /* SIG_ANALYSER_FREQ */
-	{2, 87, 0, 0, 8, 1, 0},     /* SIG_ANALYSER_I_OUT */
-	{2, 88, 0, 0, 8, 1, 0},     /* SIG_ANALYSER_Q_OUT */
-	{2, 89, 0, 0, 1, 0, 0},     /* GPS_EN */
-	{2, 89, 1, 0, 1, 0, 1},     /* GPS_POL */
-	{2, 90, 0, 1, 8, 0, 0},     /* SW_TEST_REG1 */
-	{2, 91, 2, 1, 6, 0, 0},     /* SW_TEST_REG2 */
-	{2, 92, 0, 1, 16, 0, 0},    /* SW_TEST_REG3 */
-	{2, 94, 0, 0, 4, 1, 0},     /* DATA_MNGT_STATUS */
-	{2, 95, 0, 0, 5, 1, 0},     /* DATA_MNGT_CPT_FRAME_ALLOCATED */
-	{2, 96, 0, 0, 5, 1, 0},     /* DATA_MNGT_CPT_FRAME_FINISHED */
-	{2, 97, 0, 0, 5, 1, 0},     /* DATA_MNGT_CPT_FRAME_READEN */
-	{1, 33, 0, 0, 8, 0, 0},     /* TX_TRIG_ALL (alias) */
+	{-1, 0, 0, 0, 2, 0, 0, "LGW_PAGE_REG"},
+	{-1, 0, 7, 0, 1, 0, 0, "LGW_SOFT_RESET"},
+	{-1, 1, 0, 0, 8, 1, 103, "LGW_VERSION"},
+	{-1, 2, 0, 0, 16, 0, 0, "LGW_RX_DATA_BUF_ADDR"},
+	{-1, 4, 0, 0, 8, 0, 0, "LGW_RX_DATA_BUF_DATA"},
+	{-1, 5, 0, 0, 8, 0, 0, "LGW_TX_DATA_BUF_ADDR"},
+	{-1, 6, 0, 0, 8, 0, 0, "LGW_TX_DATA_BUF_DATA"},
+	{-1, 7, 0, 0, 8, 0, 0, "LGW_CAPTURE_RAM_ADDR"},
+	{-1, 8, 0, 0, 8, 1, 0, "LGW_CAPTURE_RAM_DATA"},
+	{-1, 9, 0, 0, 8, 0, 0, "LGW_MCU_PROM_ADDR"},
+	{-1, 10, 0, 0, 8, 0, 0, "LGW_MCU_PROM_DATA"},
+	{-1, 11, 0, 0, 8, 0, 0, "LGW_RX_PACKET_DATA_FIFO_NUM_STORED"},
+	{-1, 12, 0, 0, 16, 1, 0, "LGW_RX_PACKET_DATA_FIFO_ADDR_POINTER"},
+	{-1, 14, 0, 0, 8, 1, 0, "LGW_RX_PACKET_DATA_FIFO_STATUS"},
+	{-1, 15, 0, 0, 8, 1, 0, "LGW_RX_PACKET_DATA_FIFO_PAYLOAD_SIZE"},
+	{-1, 16, 0, 0, 1, 0, 0, "LGW_MBWSSF_MODEM_ENABLE"},
+	{-1, 16, 1, 0, 1, 0, 0, "LGW_CONCENTRATOR_MODEM_ENABLE"},
+	{-1, 16, 2, 0, 1, 0, 0, "LGW_FSK_MODEM_ENABLE"},
+	{-1, 16, 3, 0, 1, 0, 0, "LGW_GLOBAL_EN"},
+	{-1, 17, 0, 0, 1, 0, 1, "LGW_CLK32M_EN"},
+	{-1, 17, 1, 0, 1, 0, 1, "LGW_CLKHS_EN"},
+	{-1, 18, 0, 0, 1, 0, 0, "LGW_START_BIST0"},
+	{-1, 18, 1, 0, 1, 0, 0, "LGW_START_BIST1"},
+	{-1, 18, 2, 0, 1, 0, 0, "LGW_CLEAR_BIST0"},
+	{-1, 18, 3, 0, 1, 0, 0, "LGW_CLEAR_BIST1"},
+	{-1, 19, 0, 0, 1, 1, 0, "LGW_BIST0_FINISHED"},
+	{-1, 19, 1, 0, 1, 1, 0, "LGW_BIST1_FINISHED"},
+	{-1, 20, 0, 0, 1, 1, 0, "LGW_MCU_AGC_PROG_RAM_BIST_STATUS"},
+	{-1, 20, 1, 0, 1, 1, 0, "LGW_MCU_ARB_PROG_RAM_BIST_STATUS"},
+	{-1, 20, 2, 0, 1, 1, 0, "LGW_CAPTURE_RAM_BIST_STATUS"},
+	{-1, 20, 3, 0, 1, 1, 0, "LGW_CHAN_FIR_RAM0_BIST_STATUS"},
+	{-1, 20, 4, 0, 1, 1, 0, "LGW_CHAN_FIR_RAM1_BIST_STATUS"},
+	{-1, 21, 0, 0, 1, 1, 0, "LGW_CORR0_RAM_BIST_STATUS"},
+	{-1, 21, 1, 0, 1, 1, 0, "LGW_CORR1_RAM_BIST_STATUS"},
+	{-1, 21, 2, 0, 1, 1, 0, "LGW_CORR2_RAM_BIST_STATUS"},
+	{-1, 21, 3, 0, 1, 1, 0, "LGW_CORR3_RAM_BIST_STATUS"},
+	{-1, 21, 4, 0, 1, 1, 0, "LGW_CORR4_RAM_BIST_STATUS"},
+	{-1, 21, 5, 0, 1, 1, 0, "LGW_CORR5_RAM_BIST_STATUS"},
+	{-1, 21, 6, 0, 1, 1, 0, "LGW_CORR6_RAM_BIST_STATUS"},
+	{-1, 21, 7, 0, 1, 1, 0, "LGW_CORR7_RAM_BIST_STATUS"},
+	{-1, 22, 0, 0, 1, 1, 0, "LGW_MODEM0_RAM0_BIST_STATUS"},
+	{-1, 22, 1, 0, 1, 1, 0, "LGW_MODEM1_RAM0_BIST_STATUS"},
+	{-1, 22, 2, 0, 1, 1, 0, "LGW_MODEM2_RAM0_BIST_STATUS"},
+	{-1, 22, 3, 0, 1, 1, 0, "LGW_MODEM3_RAM0_BIST_STATUS"},
+	{-1, 22, 4, 0, 1, 1, 0, "LGW_MODEM4_RAM0_BIST_STATUS"},
+	{-1, 22, 5, 0, 1, 1, 0, "LGW_MODEM5_RAM0_BIST_STATUS"},
+	{-1, 22, 6, 0, 1, 1, 0, "LGW_MODEM6_RAM0_BIST_STATUS"},
+	{-1, 22, 7, 0, 1, 1, 0, "LGW_MODEM7_RAM0_BIST_STATUS"},
+	{-1, 23, 0, 0, 1, 1, 0, "LGW_MODEM0_RAM1_BIST_STATUS"},
+	{-1, 23, 1, 0, 1, 1, 0, "LGW_MODEM1_RAM1_BIST_STATUS"},
+	{-1, 23, 2, 0, 1, 1, 0, "LGW_MODEM2_RAM1_BIST_STATUS"},
+	{-1, 23, 3, 0, 1, 1, 0, "LGW_MODEM3_RAM1_BIST_STATUS"},
+	{-1, 23, 4, 0, 1, 1, 0, "LGW_MODEM4_RAM1_BIST_STATUS"},
+	{-1, 23, 5, 0, 1, 1, 0, "LGW_MODEM5_RAM1_BIST_STATUS"},
+	{-1, 23, 6, 0, 1, 1, 0, "LGW_MODEM6_RAM1_BIST_STATUS"},
+	{-1, 23, 7, 0, 1, 1, 0, "LGW_MODEM7_RAM1_BIST_STATUS"},
+	{-1, 24, 0, 0, 1, 1, 0, "LGW_MODEM0_RAM2_BIST_STATUS"},
+	{-1, 24, 1, 0, 1, 1, 0, "LGW_MODEM1_RAM2_BIST_STATUS"},
+	{-1, 24, 2, 0, 1, 1, 0, "LGW_MODEM2_RAM2_BIST_STATUS"},
+	{-1, 24, 3, 0, 1, 1, 0, "LGW_MODEM3_RAM2_BIST_STATUS"},
+	{-1, 24, 4, 0, 1, 1, 0, "LGW_MODEM4_RAM2_BIST_STATUS"},
+	{-1, 24, 5, 0, 1, 1, 0, "LGW_MODEM5_RAM2_BIST_STATUS"},
+	{-1, 24, 6, 0, 1, 1, 0, "LGW_MODEM6_RAM2_BIST_STATUS"},
+	{-1, 24, 7, 0, 1, 1, 0, "LGW_MODEM7_RAM2_BIST_STATUS"},
+	{-1, 25, 0, 0, 1, 1, 0, "LGW_MODEM_MBWSSF_RAM0_BIST_STATUS"},
+	{-1, 25, 1, 0, 1, 1, 0, "LGW_MODEM_MBWSSF_RAM1_BIST_STATUS"},
+	{-1, 25, 2, 0, 1, 1, 0, "LGW_MODEM_MBWSSF_RAM2_BIST_STATUS"},
+	{-1, 26, 0, 0, 1, 1, 0, "LGW_MCU_AGC_DATA_RAM_BIST0_STATUS"},
+	{-1, 26, 1, 0, 1, 1, 0, "LGW_MCU_AGC_DATA_RAM_BIST1_STATUS"},
+	{-1, 26, 2, 0, 1, 1, 0, "LGW_MCU_ARB_DATA_RAM_BIST0_STATUS"},
+	{-1, 26, 3, 0, 1, 1, 0, "LGW_MCU_ARB_DATA_RAM_BIST1_STATUS"},
+	{-1, 26, 4, 0, 1, 1, 0, "LGW_TX_TOP_RAM_BIST0_STATUS"},
+	{-1, 26, 5, 0, 1, 1, 0, "LGW_TX_TOP_RAM_BIST1_STATUS"},
+	{-1, 26, 6, 0, 1, 1, 0, "LGW_DATA_MNGT_RAM_BIST0_STATUS"},
+	{-1, 26, 7, 0, 1, 1, 0, "LGW_DATA_MNGT_RAM_BIST1_STATUS"},
+	{-1, 27, 0, 0, 4, 0, 0, "LGW_GPIO_SELECT_INPUT"},
+	{-1, 28, 0, 0, 4, 0, 0, "LGW_GPIO_SELECT_OUTPUT"},
+	{-1, 29, 0, 0, 5, 0, 0, "LGW_GPIO_MODE"},
+	{-1, 30, 0, 0, 5, 1, 0, "LGW_GPIO_PIN_REG_IN"},
+	{-1, 31, 0, 0, 5, 0, 0, "LGW_GPIO_PIN_REG_OUT"},
+	{-1, 32, 0, 0, 8, 1, 0, "LGW_MCU_AGC_STATUS"},
+	{-1, 125, 0, 0, 8, 1, 0, "LGW_MCU_ARB_STATUS"},
+	{-1, 126, 0, 0, 8, 1, 1, "LGW_CHIP_ID"},
+	{-1, 127, 0, 0, 1, 0, 1, "LGW_EMERGENCY_FORCE_HOST_CTRL"},
+	{0, 33, 0, 0, 1, 0, 0, "LGW_RX_INVERT_IQ"},
+	{0, 33, 1, 0, 1, 0, 1, "LGW_MODEM_INVERT_IQ"},
+	{0, 33, 2, 0, 1, 0, 0, "LGW_MBWSSF_MODEM_INVERT_IQ"},
+	{0, 33, 3, 0, 1, 0, 0, "LGW_RX_EDGE_SELECT"},
+	{0, 33, 4, 0, 1, 0, 0, "LGW_MISC_RADIO_EN"},
+	{0, 33, 5, 0, 1, 0, 0, "LGW_FSK_MODEM_INVERT_IQ"},
+	{0, 34, 0, 0, 4, 0, 7, "LGW_FILTER_GAIN"},
+	{0, 35, 0, 0, 8, 0, 240, "LGW_RADIO_SELECT"},
+	{0, 36, 0, 1, 13, 0, -384, "LGW_IF_FREQ_0"},
+	{0, 38, 0, 1, 13, 0, -128, "LGW_IF_FREQ_1"},
+	{0, 40, 0, 1, 13, 0, 128, "LGW_IF_FREQ_2"},
+	{0, 42, 0, 1, 13, 0, 384, "LGW_IF_FREQ_3"},
+	{0, 44, 0, 1, 13, 0, -384, "LGW_IF_FREQ_4"},
+	{0, 46, 0, 1, 13, 0, -128, "LGW_IF_FREQ_5"},
+	{0, 48, 0, 1, 13, 0, 128, "LGW_IF_FREQ_6"},
+	{0, 50, 0, 1, 13, 0, 384, "LGW_IF_FREQ_7"},
+	{0, 52, 0, 1, 13, 0, 0, "LGW_IF_FREQ_8"},
+	{0, 54, 0, 1, 13, 0, 0, "LGW_IF_FREQ_9"},
+	{0, 64, 0, 0, 1, 0, 0, "LGW_CHANN_OVERRIDE_AGC_GAIN"},
+	{0, 64, 1, 0, 4, 0, 7, "LGW_CHANN_AGC_GAIN"},
+	{0, 65, 0, 0, 7, 0, 0, "LGW_CORR0_DETECT_EN"},
+	{0, 66, 0, 0, 7, 0, 0, "LGW_CORR1_DETECT_EN"},
+	{0, 67, 0, 0, 7, 0, 0, "LGW_CORR2_DETECT_EN"},
+	{0, 68, 0, 0, 7, 0, 0, "LGW_CORR3_DETECT_EN"},
+	{0, 69, 0, 0, 7, 0, 0, "LGW_CORR4_DETECT_EN"},
+	{0, 70, 0, 0, 7, 0, 0, "LGW_CORR5_DETECT_EN"},
+	{0, 71, 0, 0, 7, 0, 0, "LGW_CORR6_DETECT_EN"},
+	{0, 72, 0, 0, 7, 0, 0, "LGW_CORR7_DETECT_EN"},
+	{0, 73, 0, 0, 1, 0, 0, "LGW_CORR_SAME_PEAKS_OPTION_SF6"},
+	{0, 73, 1, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF7"},
+	{0, 73, 2, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF8"},
+	{0, 73, 3, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF9"},
+	{0, 73, 4, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF10"},
+	{0, 73, 5, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF11"},
+	{0, 73, 6, 0, 1, 0, 1, "LGW_CORR_SAME_PEAKS_OPTION_SF12"},
+	{0, 74, 0, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF6"},
+	{0, 74, 4, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF7"},
+	{0, 75, 0, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF8"},
+	{0, 75, 4, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF9"},
+	{0, 76, 0, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF10"},
+	{0, 76, 4, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF11"},
+	{0, 77, 0, 0, 4, 0, 4, "LGW_CORR_SIG_NOISE_RATIO_SF12"},
+	{0, 78, 0, 0, 4, 0, 4, "LGW_CORR_NUM_SAME_PEAK"},
+	{0, 78, 4, 0, 3, 0, 5, "LGW_CORR_MAC_GAIN"},
+	{0, 81, 0, 0, 12, 0, 0, "LGW_ADJUST_MODEM_START_OFFSET_RDX4"},
+	{0, 83, 0, 0, 12, 0, 4092, "LGW_ADJUST_MODEM_START_OFFSET_SF12_RDX4"},
+	{0, 85, 0, 0, 8, 0, 7, "LGW_DBG_CORR_SELECT_SF"},
+	{0, 86, 0, 0, 8, 0, 0, "LGW_DBG_CORR_SELECT_CHANNEL"},
+	{0, 87, 0, 0, 8, 1, 0, "LGW_DBG_DETECT_CPT"},
+	{0, 88, 0, 0, 8, 1, 0, "LGW_DBG_SYMB_CPT"},
+	{0, 89, 0, 0, 1, 0, 1, "LGW_CHIRP_INVERT_RX"},
+	{0, 89, 1, 0, 1, 0, 1, "LGW_DC_NOTCH_EN"},
+	{0, 90, 0, 0, 1, 0, 0, "LGW_IMPLICIT_CRC_EN"},
+	{0, 90, 1, 0, 3, 0, 0, "LGW_IMPLICIT_CODING_RATE"},
+	{0, 91, 0, 0, 8, 0, 0, "LGW_IMPLICIT_PAYLOAD_LENGHT"},
+	{0, 92, 0, 0, 8, 0, 29, "LGW_FREQ_TO_TIME_INVERT"},
+	{0, 93, 0, 0, 6, 0, 9, "LGW_FREQ_TO_TIME_DRIFT"},
+	{0, 94, 0, 0, 2, 0, 2, "LGW_PAYLOAD_FINE_TIMING_GAIN"},
+	{0, 94, 2, 0, 2, 0, 1, "LGW_PREAMBLE_FINE_TIMING_GAIN"},
+	{0, 94, 4, 0, 2, 0, 0, "LGW_TRACKING_INTEGRAL"},
+	{0, 95, 0, 0, 4, 0, 1, "LGW_FRAME_SYNCH_PEAK1_POS"},
+	{0, 95, 4, 0, 4, 0, 2, "LGW_FRAME_SYNCH_PEAK2_POS"},
+	{0, 96, 0, 0, 16, 0, 10, "LGW_PREAMBLE_SYMB1_NB"},
+	{0, 98, 0, 0, 1, 0, 1, "LGW_FRAME_SYNCH_GAIN"},
+	{0, 98, 1, 0, 1, 0, 1, "LGW_SYNCH_DETECT_TH"},
+	{0, 99, 0, 0, 4, 0, 8, "LGW_LLR_SCALE"},
+	{0, 99, 4, 0, 2, 0, 2, "LGW_SNR_AVG_CST"},
+	{0, 100, 0, 0, 7, 0, 0, "LGW_PPM_OFFSET"},
+	{0, 101, 0, 0, 8, 0, 255, "LGW_MAX_PAYLOAD_LEN"},
+	{0, 102, 0, 0, 1, 0, 1, "LGW_ONLY_CRC_EN"},
+	{0, 103, 0, 0, 8, 0, 0, "LGW_ZERO_PAD"},
+	{0, 104, 0, 0, 4, 0, 8, "LGW_DEC_GAIN_OFFSET"},
+	{0, 104, 4, 0, 4, 0, 7, "LGW_CHAN_GAIN_OFFSET"},
+	{0, 105, 1, 0, 1, 0, 1, "LGW_FORCE_HOST_RADIO_CTRL"},
+	{0, 105, 2, 0, 1, 0, 1, "LGW_FORCE_HOST_FE_CTRL"},
+	{0, 105, 3, 0, 1, 0, 1, "LGW_FORCE_DEC_FILTER_GAIN"},
+	{0, 106, 0, 0, 1, 0, 1, "LGW_MCU_RST_0"},
+	{0, 106, 1, 0, 1, 0, 1, "LGW_MCU_RST_1"},
+	{0, 106, 2, 0, 1, 0, 0, "LGW_MCU_SELECT_MUX_0"},
+	{0, 106, 3, 0, 1, 0, 0, "LGW_MCU_SELECT_MUX_1"},
+	{0, 106, 4, 0, 1, 1, 0, "LGW_MCU_CORRUPTION_DETECTED_0"},
+	{0, 106, 5, 0, 1, 1, 0, "LGW_MCU_CORRUPTION_DETECTED_1"},
+	{0, 106, 6, 0, 1, 0, 0, "LGW_MCU_SELECT_EDGE_0"},
+	{0, 106, 7, 0, 1, 0, 0, "LGW_MCU_SELECT_EDGE_1"},
+	{0, 107, 0, 0, 8, 0, 1, "LGW_CHANN_SELECT_RSSI"},
+	{0, 108, 0, 0, 8, 0, 32, "LGW_RSSI_BB_DEFAULT_VALUE"},
+	{0, 109, 0, 0, 8, 0, 100, "LGW_RSSI_DEC_DEFAULT_VALUE"},
+	{0, 110, 0, 0, 8, 0, 100, "LGW_RSSI_CHANN_DEFAULT_VALUE"},
+	{0, 111, 0, 0, 5, 0, 7, "LGW_RSSI_BB_FILTER_ALPHA"},
+	{0, 112, 0, 0, 5, 0, 5, "LGW_RSSI_DEC_FILTER_ALPHA"},
+	{0, 113, 0, 0, 5, 0, 8, "LGW_RSSI_CHANN_FILTER_ALPHA"},
+	{0, 114, 0, 0, 6, 0, 0, "LGW_IQ_MISMATCH_A_AMP_COEFF"},
+	{0, 115, 0, 0, 6, 0, 0, "LGW_IQ_MISMATCH_A_PHI_COEFF"},
+	{0, 116, 0, 0, 6, 0, 0, "LGW_IQ_MISMATCH_B_AMP_COEFF"},
+	{0, 116, 6, 0, 1, 0, 0, "LGW_IQ_MISMATCH_B_SEL_I"},
+	{0, 117, 0, 0, 6, 0, 0, "LGW_IQ_MISMATCH_B_PHI_COEFF"},
+	{1, 33, 0, 0, 1, 0, 0, "LGW_TX_TRIG_IMMEDIATE"},
+	{1, 33, 1, 0, 1, 0, 0, "LGW_TX_TRIG_DELAYED"},
+	{1, 33, 2, 0, 1, 0, 0, "LGW_TX_TRIG_GPS"},
+	{1, 34, 0, 0, 16, 0, 0, "LGW_TX_START_DELAY"},
+	{1, 36, 0, 0, 4, 0, 1, "LGW_TX_FRAME_SYNCH_PEAK1_POS"},
+	{1, 36, 4, 0, 4, 0, 2, "LGW_TX_FRAME_SYNCH_PEAK2_POS"},
+	{1, 37, 0, 0, 3, 0, 0, "LGW_TX_RAMP_DURATION"},
+	{1, 39, 0, 1, 8, 0, 0, "LGW_TX_OFFSET_I"},
+	{1, 40, 0, 1, 8, 0, 0, "LGW_TX_OFFSET_Q"},
+	{1, 41, 0, 0, 1, 0, 0, "LGW_TX_MODE"},
+	{1, 41, 1, 0, 4, 0, 0, "LGW_TX_ZERO_PAD"},
+	{1, 41, 5, 0, 1, 0, 0, "LGW_TX_EDGE_SELECT"},
+	{1, 41, 6, 0, 1, 0, 0, "LGW_TX_EDGE_SELECT_TOP"},
+	{1, 42, 0, 0, 2, 0, 0, "LGW_TX_GAIN"},
+	{1, 42, 2, 0, 3, 0, 5, "LGW_TX_CHIRP_LOW_PASS"},
+	{1, 42, 5, 0, 2, 0, 0, "LGW_TX_FCC_WIDEBAND"},
+	{1, 42, 7, 0, 1, 0, 1, "LGW_TX_SWAP_IQ"},
+	{1, 43, 0, 0, 1, 0, 0, "LGW_MBWSSF_IMPLICIT_HEADER"},
+	{1, 43, 1, 0, 1, 0, 0, "LGW_MBWSSF_IMPLICIT_CRC_EN"},
+	{1, 43, 2, 0, 3, 0, 0, "LGW_MBWSSF_IMPLICIT_CODING_RATE"},
+	{1, 44, 0, 0, 8, 0, 0, "LGW_MBWSSF_IMPLICIT_PAYLOAD_LENGHT"},
+	{1, 45, 0, 0, 1, 0, 1, "LGW_MBWSSF_AGC_FREEZE_ON_DETECT"},
+	{1, 46, 0, 0, 4, 0, 1, "LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS"},
+	{1, 46, 4, 0, 4, 0, 2, "LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS"},
+	{1, 47, 0, 0, 16, 0, 10, "LGW_MBWSSF_PREAMBLE_SYMB1_NB"},
+	{1, 49, 0, 0, 1, 0, 1, "LGW_MBWSSF_FRAME_SYNCH_GAIN"},
+	{1, 49, 1, 0, 1, 0, 1, "LGW_MBWSSF_SYNCH_DETECT_TH"},
+	{1, 50, 0, 0, 8, 0, 10, "LGW_MBWSSF_DETECT_MIN_SINGLE_PEAK"},
+	{1, 51, 0, 0, 3, 0, 3, "LGW_MBWSSF_DETECT_TRIG_SAME_PEAK_NB"},
+	{1, 52, 0, 0, 8, 0, 29, "LGW_MBWSSF_FREQ_TO_TIME_INVERT"},
+	{1, 53, 0, 0, 6, 0, 36, "LGW_MBWSSF_FREQ_TO_TIME_DRIFT"},
+	{1, 54, 0, 0, 12, 0, 0, "LGW_MBWSSF_PPM_CORRECTION"},
+	{1, 56, 0, 0, 2, 0, 2, "LGW_MBWSSF_PAYLOAD_FINE_TIMING_GAIN"},
+	{1, 56, 2, 0, 2, 0, 1, "LGW_MBWSSF_PREAMBLE_FINE_TIMING_GAIN"},
+	{1, 56, 4, 0, 2, 0, 0, "LGW_MBWSSF_TRACKING_INTEGRAL"},
+	{1, 57, 0, 0, 8, 0, 0, "LGW_MBWSSF_ZERO_PAD"},
+	{1, 58, 0, 0, 2, 0, 0, "LGW_MBWSSF_MODEM_BW"},
+	{1, 58, 2, 0, 1, 0, 0, "LGW_MBWSSF_RADIO_SELECT"},
+	{1, 58, 3, 0, 1, 0, 1, "LGW_MBWSSF_RX_CHIRP_INVERT"},
+	{1, 59, 0, 0, 4, 0, 8, "LGW_MBWSSF_LLR_SCALE"},
+	{1, 59, 4, 0, 2, 0, 3, "LGW_MBWSSF_SNR_AVG_CST"},
+	{1, 59, 6, 0, 1, 0, 0, "LGW_MBWSSF_PPM_OFFSET"},
+	{1, 60, 0, 0, 4, 0, 7, "LGW_MBWSSF_RATE_SF"},
+	{1, 60, 4, 0, 1, 0, 1, "LGW_MBWSSF_ONLY_CRC_EN"},
+	{1, 61, 0, 0, 8, 0, 255, "LGW_MBWSSF_MAX_PAYLOAD_LEN"},
+	{1, 62, 0, 0, 8, 1, 128, "LGW_TX_STATUS"},
+	{1, 63, 0, 0, 3, 0, 0, "LGW_FSK_CH_BW_EXPO"},
+	{1, 63, 3, 0, 3, 0, 0, "LGW_FSK_RSSI_LENGTH"},
+	{1, 63, 6, 0, 1, 0, 0, "LGW_FSK_RX_INVERT"},
+	{1, 63, 7, 0, 1, 0, 0, "LGW_FSK_PKT_MODE"},
+	{1, 64, 0, 0, 3, 0, 0, "LGW_FSK_PSIZE"},
+	{1, 64, 3, 0, 1, 0, 0, "LGW_FSK_CRC_EN"},
+	{1, 64, 4, 0, 2, 0, 0, "LGW_FSK_DCFREE_ENC"},
+	{1, 64, 6, 0, 1, 0, 0, "LGW_FSK_CRC_IBM"},
+	{1, 65, 0, 0, 5, 0, 0, "LGW_FSK_ERROR_OSR_TOL"},
+	{1, 65, 7, 0, 1, 0, 0, "LGW_FSK_RADIO_SELECT"},
+	{1, 66, 0, 0, 16, 0, 0, "LGW_FSK_BR_RATIO"},
+	{1, 68, 0, 0, 32, 0, 0, "LGW_FSK_REF_PATTERN_LSB"},
+	{1, 72, 0, 0, 32, 0, 0, "LGW_FSK_REF_PATTERN_MSB"},
+	{1, 76, 0, 0, 8, 0, 0, "LGW_FSK_PKT_LENGTH"},
+	{1, 77, 0, 0, 1, 0, 1, "LGW_FSK_TX_GAUSSIAN_EN"},
+	{1, 77, 1, 0, 2, 0, 0, "LGW_FSK_TX_GAUSSIAN_SELECT_BT"},
+	{1, 77, 3, 0, 1, 0, 1, "LGW_FSK_TX_PATTERN_EN"},
+	{1, 77, 4, 0, 1, 0, 0, "LGW_FSK_TX_PREAMBLE_SEQ"},
+	{1, 77, 5, 0, 3, 0, 0, "LGW_FSK_TX_PSIZE"},
+	{1, 80, 0, 0, 8, 0, 0, "LGW_FSK_NODE_ADRS"},
+	{1, 81, 0, 0, 8, 0, 0, "LGW_FSK_BROADCAST"},
+	{1, 82, 0, 0, 1, 0, 1, "LGW_FSK_AUTO_AFC_ON"},
+	{1, 83, 0, 0, 10, 0, 0, "LGW_FSK_PATTERN_TIMEOUT_CFG"},
+	{2, 33, 0, 0, 8, 0, 0, "LGW_SPI_RADIO_A__DATA"},
+	{2, 34, 0, 0, 8, 1, 0, "LGW_SPI_RADIO_A__DATA_READBACK"},
+	{2, 35, 0, 0, 8, 0, 0, "LGW_SPI_RADIO_A__ADDR"},
+	{2, 37, 0, 0, 1, 0, 0, "LGW_SPI_RADIO_A__CS"},
+	{2, 38, 0, 0, 8, 0, 0, "LGW_SPI_RADIO_B__DATA"},
+	{2, 39, 0, 0, 8, 1, 0, "LGW_SPI_RADIO_B__DATA_READBACK"},
+	{2, 40, 0, 0, 8, 0, 0, "LGW_SPI_RADIO_B__ADDR"},
+	{2, 42, 0, 0, 1, 0, 0, "LGW_SPI_RADIO_B__CS"},
+	{2, 43, 0, 0, 1, 0, 0, "LGW_RADIO_A_EN"},
+	{2, 43, 1, 0, 1, 0, 0, "LGW_RADIO_B_EN"},
+	{2, 43, 2, 0, 1, 0, 1, "LGW_RADIO_RST"},
+	{2, 43, 3, 0, 1, 0, 0, "LGW_LNA_A_EN"},
+	{2, 43, 4, 0, 1, 0, 0, "LGW_PA_A_EN"},
+	{2, 43, 5, 0, 1, 0, 0, "LGW_LNA_B_EN"},
+	{2, 43, 6, 0, 1, 0, 0, "LGW_PA_B_EN"},
+	{2, 44, 0, 0, 2, 0, 0, "LGW_PA_GAIN"},
+	{2, 45, 0, 0, 4, 0, 2, "LGW_LNA_A_CTRL_LUT"},
+	{2, 45, 4, 0, 4, 0, 4, "LGW_PA_A_CTRL_LUT"},
+	{2, 46, 0, 0, 4, 0, 2, "LGW_LNA_B_CTRL_LUT"},
+	{2, 46, 4, 0, 4, 0, 4, "LGW_PA_B_CTRL_LUT"},
+	{2, 47, 0, 0, 5, 0, 0, "LGW_CAPTURE_SOURCE"},
+	{2, 47, 5, 0, 1, 0, 0, "LGW_CAPTURE_START"},
+	{2, 47, 6, 0, 1, 0, 0, "LGW_CAPTURE_FORCE_TRIGGER"},
+	{2, 47, 7, 0, 1, 0, 0, "LGW_CAPTURE_WRAP"},
+	{2, 48, 0, 0, 16, 0, 0, "LGW_CAPTURE_PERIOD"},
+	{2, 51, 0, 0, 8, 1, 0, "LGW_MODEM_STATUS"},
+	{2, 52, 0, 0, 8, 1, 0, "LGW_VALID_HEADER_COUNTER_0"},
+	{2, 54, 0, 0, 8, 1, 0, "LGW_VALID_PACKET_COUNTER_0"},
+	{2, 56, 0, 0, 8, 1, 0, "LGW_VALID_HEADER_COUNTER_MBWSSF"},
+	{2, 57, 0, 0, 8, 1, 0, "LGW_VALID_HEADER_COUNTER_FSK"},
+	{2, 58, 0, 0, 8, 1, 0, "LGW_VALID_PACKET_COUNTER_MBWSSF"},
+	{2, 59, 0, 0, 8, 1, 0, "LGW_VALID_PACKET_COUNTER_FSK"},
+	{2, 60, 0, 0, 8, 1, 0, "LGW_CHANN_RSSI"},
+	{2, 61, 0, 0, 8, 1, 0, "LGW_BB_RSSI"},
+	{2, 62, 0, 0, 8, 1, 0, "LGW_DEC_RSSI"},
+	{2, 63, 0, 0, 8, 1, 0, "LGW_DBG_MCU_DATA"},
+	{2, 64, 0, 0, 8, 1, 0, "LGW_DBG_ARB_MCU_RAM_DATA"},
+	{2, 65, 0, 0, 8, 1, 0, "LGW_DBG_AGC_MCU_RAM_DATA"},
+	{2, 66, 0, 0, 16, 1, 0, "LGW_NEXT_PACKET_CNT"},
+	{2, 68, 0, 0, 16, 1, 0, "LGW_ADDR_CAPTURE_COUNT"},
+	{2, 70, 0, 0, 32, 1, 0, "LGW_TIMESTAMP"},
+	{2, 74, 0, 0, 4, 1, 0, "LGW_DBG_CHANN0_GAIN"},
+	{2, 74, 4, 0, 4, 1, 0, "LGW_DBG_CHANN1_GAIN"},
+	{2, 75, 0, 0, 4, 1, 0, "LGW_DBG_CHANN2_GAIN"},
+	{2, 75, 4, 0, 4, 1, 0, "LGW_DBG_CHANN3_GAIN"},
+	{2, 76, 0, 0, 4, 1, 0, "LGW_DBG_CHANN4_GAIN"},
+	{2, 76, 4, 0, 4, 1, 0, "LGW_DBG_CHANN5_GAIN"},
+	{2, 77, 0, 0, 4, 1, 0, "LGW_DBG_CHANN6_GAIN"},
+	{2, 77, 4, 0, 4, 1, 0, "LGW_DBG_CHANN7_GAIN"},
+	{2, 78, 0, 0, 4, 1, 0, "LGW_DBG_DEC_FILT_GAIN"},
+	{2, 79, 0, 0, 3, 1, 0, "LGW_SPI_DATA_FIFO_PTR"},
+	{2, 79, 3, 0, 3, 1, 0, "LGW_PACKET_DATA_FIFO_PTR"},
+	{2, 80, 0, 0, 8, 0, 0, "LGW_DBG_ARB_MCU_RAM_ADDR"},
+	{2, 81, 0, 0, 8, 0, 0, "LGW_DBG_AGC_MCU_RAM_ADDR"},
+	{2, 82, 0, 0, 1, 0, 0, "LGW_SPI_MASTER_CHIP_SELECT_POLARITY"},
+	{2, 82, 1, 0, 1, 0, 0, "LGW_SPI_MASTER_CPOL"},
+	{2, 82, 2, 0, 1, 0, 0, "LGW_SPI_MASTER_CPHA"},
+	{2, 83, 0, 0, 1, 0, 0, "LGW_SIG_GEN_ANALYSER_MUX_SEL"},
+	{2, 84, 0, 0, 1, 0, 0, "LGW_SIG_GEN_EN"},
+	{2, 84, 1, 0, 1, 0, 0, "LGW_SIG_ANALYSER_EN"},
+	{2, 84, 2, 0, 2, 0, 0, "LGW_SIG_ANALYSER_AVG_LEN"},
+	{2, 84, 4, 0, 3, 0, 0, "LGW_SIG_ANALYSER_PRECISION"},
+	{2, 84, 7, 0, 1, 1, 0, "LGW_SIG_ANALYSER_VALID_OUT"},
+	{2, 85, 0, 0, 8, 0, 0, "LGW_SIG_GEN_FREQ"},
+	{2, 86, 0, 0, 8, 0, 0, "LGW_SIG_ANALYSER_FREQ"},
+	{2, 87, 0, 0, 8, 1, 0, "LGW_SIG_ANALYSER_I_OUT"},
+	{2, 88, 0, 0, 8, 1, 0, "LGW_SIG_ANALYSER_Q_OUT"},
+	{2, 89, 0, 0, 1, 0, 0, "LGW_GPS_EN"},
+	{2, 89, 1, 0, 1, 0, 1, "LGW_GPS_POL"},
+	{2, 90, 0, 1, 8, 0, 0, "LGW_SW_TEST_REG1"},
+	{2, 91, 2, 1, 6, 0, 0, "LGW_SW_TEST_REG2"},
+	{2, 92, 0, 1, 16, 0, 0, "LGW_SW_TEST_REG3"},
+	{2, 94, 0, 0, 4, 1, 0, "LGW_DATA_MNGT_STATUS"},
+	{2, 95, 0, 0, 5, 1, 0, "LGW_DATA_MNGT_CPT_FRAME_ALLOCATED"},
+	{2, 96, 0, 0, 5, 1, 0, "LGW_DATA_MNGT_CPT_FRAME_FINISHED"},
+	{2, 97, 0, 0, 5, 1, 0, "LGW_DATA_MNGT_CPT_FRAME_READEN"},
+	{1, 33, 0, 0, 8, 0, 0, "LGW_TX_TRIG_ALL"}, /* alias */
 }
 
 const (
-	LGW_PAGE_REG                            uint16 = 0
-	LGW_SOFT_RESET                          uint16 = 1
-	LGW_VERSION                             uint16 = 2
-	LGW_RX_DATA_BUF_ADDR                    uint16 = 3
-	LGW_RX_DATA_BUF_DATA                    uint16 = 4
-	LGW_TX_DATA_BUF_ADDR                    uint16 = 5
-	LGW_TX_DATA_BUF_DATA                    uint16 = 6
-	LGW_CAPTURE_RAM_ADDR                    uint16 = 7
-	LGW_CAPTURE_RAM_DATA                    uint16 = 8
-	LGW_MCU_PROM_ADDR                       uint16 = 9
-	LGW_MCU_PROM_DATA                       uint16 = 10
-	LGW_RX_PACKET_DATA_FIFO_NUM_STORED      uint16 = 11
-	LGW_RX_PACKET_DATA_FIFO_ADDR_POINTER    uint16 = 12
-	LGW_RX_PACKET_DATA_FIFO_STATUS          uint16 = 13
-	LGW_RX_PACKET_DATA_FIFO_PAYLOAD_SIZE    uint16 = 14
-	LGW_MBWSSF_MODEM_ENABLE                 uint16 = 15
-	LGW_CONCENTRATOR_MODEM_ENABLE           uint16 = 16
-	LGW_FSK_MODEM_ENABLE                    uint16 = 17
-	LGW_GLOBAL_EN                           uint16 = 18
-	LGW_CLK32M_EN                           uint16 = 19
-	LGW_CLKHS_EN                            uint16 = 20
-	LGW_START_BIST0                         uint16 = 21
-	LGW_START_BIST1                         uint16 = 22
-	LGW_CLEAR_BIST0                         uint16 = 23
-	LGW_CLEAR_BIST1                         uint16 = 24
-	LGW_BIST0_FINISHED                      uint16 = 25
-	LGW_BIST1_FINISHED                      uint16 = 26
-	LGW_MCU_AGC_PROG_RAM_BIST_STATUS        uint16 = 27
-	LGW_MCU_ARB_PROG_RAM_BIST_STATUS        uint16 = 28
-	LGW_CAPTURE_RAM_BIST_STATUS             uint16 = 29
-	LGW_CHAN_FIR_RAM0_BIST_STATUS           uint16 = 30
-	LGW_CHAN_FIR_RAM1_BIST_STATUS           uint16 = 31
-	LGW_CORR0_RAM_BIST_STATUS               uint16 = 32
-	LGW_CORR1_RAM_BIST_STATUS               uint16 = 33
-	LGW_CORR2_RAM_BIST_STATUS               uint16 = 34
-	LGW_CORR3_RAM_BIST_STATUS               uint16 = 35
-	LGW_CORR4_RAM_BIST_STATUS               uint16 = 36
-	LGW_CORR5_RAM_BIST_STATUS               uint16 = 37
-	LGW_CORR6_RAM_BIST_STATUS               uint16 = 38
-	LGW_CORR7_RAM_BIST_STATUS               uint16 = 39
-	LGW_MODEM0_RAM0_BIST_STATUS             uint16 = 40
-	LGW_MODEM1_RAM0_BIST_STATUS             uint16 = 41
-	LGW_MODEM2_RAM0_BIST_STATUS             uint16 = 42
-	LGW_MODEM3_RAM0_BIST_STATUS             uint16 = 43
-	LGW_MODEM4_RAM0_BIST_STATUS             uint16 = 44
-	LGW_MODEM5_RAM0_BIST_STATUS             uint16 = 45
-	LGW_MODEM6_RAM0_BIST_STATUS             uint16 = 46
-	LGW_MODEM7_RAM0_BIST_STATUS             uint16 = 47
-	LGW_MODEM0_RAM1_BIST_STATUS             uint16 = 48
-	LGW_MODEM1_RAM1_BIST_STATUS             uint16 = 49
-	LGW_MODEM2_RAM1_BIST_STATUS             uint16 = 50
-	LGW_MODEM3_RAM1_BIST_STATUS             uint16 = 51
-	LGW_MODEM4_RAM1_BIST_STATUS             uint16 = 52
-	LGW_MODEM5_RAM1_BIST_STATUS             uint16 = 53
-	LGW_MODEM6_RAM1_BIST_STATUS             uint16 = 54
-	LGW_MODEM7_RAM1_BIST_STATUS             uint16 = 55
-	LGW_MODEM0_RAM2_BIST_STATUS             uint16 = 56
-	LGW_MODEM1_RAM2_BIST_STATUS             uint16 = 57
-	LGW_MODEM2_RAM2_BIST_STATUS             uint16 = 58
-	LGW_MODEM3_RAM2_BIST_STATUS             uint16 = 59
-	LGW_MODEM4_RAM2_BIST_STATUS             uint16 = 60
-	LGW_MODEM5_RAM2_BIST_STATUS             uint16 = 61
-	LGW_MODEM6_RAM2_BIST_STATUS             uint16 = 62
-	LGW_MODEM7_RAM2_BIST_STATUS             uint16 = 63
-	LGW_MODEM_MBWSSF_RAM0_BIST_STATUS       uint16 = 64
-	LGW_MODEM_MBWSSF_RAM1_BIST_STATUS       uint16 = 65
-	LGW_MODEM_MBWSSF_RAM2_BIST_STATUS       uint16 = 66
-	LGW_MCU_AGC_DATA_RAM_BIST0_STATUS       uint16 = 67
-	LGW_MCU_AGC_DATA_RAM_BIST1_STATUS       uint16 = 68
-	LGW_MCU_ARB_DATA_RAM_BIST0_STATUS       uint16 = 69
-	LGW_MCU_ARB_DATA_RAM_BIST1_STATUS       uint16 = 70
-	LGW_TX_TOP_RAM_BIST0_STATUS             uint16 = 71
-	LGW_TX_TOP_RAM_BIST1_STATUS             uint16 = 72
-	LGW_DATA_MNGT_RAM_BIST0_STATUS          uint16 = 73
-	LGW_DATA_MNGT_RAM_BIST1_STATUS          uint16 = 74
-	LGW_GPIO_SELECT_INPUT                   uint16 = 75
-	LGW_GPIO_SELECT_OUTPUT                  uint16 = 76
-	LGW_GPIO_MODE                           uint16 = 77
-	LGW_GPIO_PIN_REG_IN                     uint16 = 78
-	LGW_GPIO_PIN_REG_OUT                    uint16 = 79
-	LGW_MCU_AGC_STATUS                      uint16 = 80
-	LGW_MCU_ARB_STATUS                      uint16 = 81
-	LGW_CHIP_ID                             uint16 = 82
-	LGW_EMERGENCY_FORCE_HOST_CTRL           uint16 = 83
-	LGW_RX_INVERT_IQ                        uint16 = 84
-	LGW_MODEM_INVERT_IQ                     uint16 = 85
-	LGW_MBWSSF_MODEM_INVERT_IQ              uint16 = 86
-	LGW_RX_EDGE_SELECT                      uint16 = 87
-	LGW_MISC_RADIO_EN                       uint16 = 88
-	LGW_FSK_MODEM_INVERT_IQ                 uint16 = 89
-	LGW_FILTER_GAIN                         uint16 = 90
-	LGW_RADIO_SELECT                        uint16 = 91
-	LGW_IF_FREQ_0                           uint16 = 92
-	LGW_IF_FREQ_1                           uint16 = 93
-	LGW_IF_FREQ_2                           uint16 = 94
-	LGW_IF_FREQ_3                           uint16 = 95
-	LGW_IF_FREQ_4                           uint16 = 96
-	LGW_IF_FREQ_5                           uint16 = 97
-	LGW_IF_FREQ_6                           uint16 = 98
-	LGW_IF_FREQ_7                           uint16 = 99
-	LGW_IF_FREQ_8                           uint16 = 100
-	LGW_IF_FREQ_9                           uint16 = 101
-	LGW_CHANN_OVERRIDE_AGC_GAIN             uint16 = 102
-	LGW_CHANN_AGC_GAIN                      uint16 = 103
-	LGW_CORR0_DETECT_EN                     uint16 = 104
-	LGW_CORR1_DETECT_EN                     uint16 = 105
-	LGW_CORR2_DETECT_EN                     uint16 = 106
-	LGW_CORR3_DETECT_EN                     uint16 = 107
-	LGW_CORR4_DETECT_EN                     uint16 = 108
-	LGW_CORR5_DETECT_EN                     uint16 = 109
-	LGW_CORR6_DETECT_EN                     uint16 = 110
-	LGW_CORR7_DETECT_EN                     uint16 = 111
-	LGW_CORR_SAME_PEAKS_OPTION_SF6          uint16 = 112
-	LGW_CORR_SAME_PEAKS_OPTION_SF7          uint16 = 113
-	LGW_CORR_SAME_PEAKS_OPTION_SF8          uint16 = 114
-	LGW_CORR_SAME_PEAKS_OPTION_SF9          uint16 = 115
-	LGW_CORR_SAME_PEAKS_OPTION_SF10         uint16 = 116
-	LGW_CORR_SAME_PEAKS_OPTION_SF11         uint16 = 117
-	LGW_CORR_SAME_PEAKS_OPTION_SF12         uint16 = 118
-	LGW_CORR_SIG_NOISE_RATIO_SF6            uint16 = 119
-	LGW_CORR_SIG_NOISE_RATIO_SF7            uint16 = 120
-	LGW_CORR_SIG_NOISE_RATIO_SF8            uint16 = 121
-	LGW_CORR_SIG_NOISE_RATIO_SF9            uint16 = 122
-	LGW_CORR_SIG_NOISE_RATIO_SF10           uint16 = 123
-	LGW_CORR_SIG_NOISE_RATIO_SF11           uint16 = 124
-	LGW_CORR_SIG_NOISE_RATIO_SF12           uint16 = 125
-	LGW_CORR_NUM_SAME_PEAK                  uint16 = 126
-	LGW_CORR_MAC_GAIN                       uint16 = 127
-	LGW_ADJUST_MODEM_START_OFFSET_RDX4      uint16 = 128
-	LGW_ADJUST_MODEM_START_OFFSET_SF12_RDX4 uint16 = 129
-	LGW_DBG_CORR_SELECT_SF                  uint16 = 130
-	LGW_DBG_CORR_SELECT_CHANNEL             uint16 = 131
-	LGW_DBG_DETECT_CPT                      uint16 = 132
-	LGW_DBG_SYMB_CPT                        uint16 = 133
-	LGW_CHIRP_INVERT_RX                     uint16 = 134
-	LGW_DC_NOTCH_EN                         uint16 = 135
-	LGW_IMPLICIT_CRC_EN                     uint16 = 136
-	LGW_IMPLICIT_CODING_RATE                uint16 = 137
-	LGW_IMPLICIT_PAYLOAD_LENGHT             uint16 = 138
-	LGW_FREQ_TO_TIME_INVERT                 uint16 = 139
-	LGW_FREQ_TO_TIME_DRIFT                  uint16 = 140
-	LGW_PAYLOAD_FINE_TIMING_GAIN            uint16 = 141
-	LGW_PREAMBLE_FINE_TIMING_GAIN           uint16 = 142
-	LGW_TRACKING_INTEGRAL                   uint16 = 143
-	LGW_FRAME_SYNCH_PEAK1_POS               uint16 = 144
-	LGW_FRAME_SYNCH_PEAK2_POS               uint16 = 145
-	LGW_PREAMBLE_SYMB1_NB                   uint16 = 146
-	LGW_FRAME_SYNCH_GAIN                    uint16 = 147
-	LGW_SYNCH_DETECT_TH                     uint16 = 148
-	LGW_LLR_SCALE                           uint16 = 149
-	LGW_SNR_AVG_CST                         uint16 = 150
-	LGW_PPM_OFFSET                          uint16 = 151
-	LGW_MAX_PAYLOAD_LEN                     uint16 = 152
-	LGW_ONLY_CRC_EN                         uint16 = 153
-	LGW_ZERO_PAD                            uint16 = 154
-	LGW_DEC_GAIN_OFFSET                     uint16 = 155
-	LGW_CHAN_GAIN_OFFSET                    uint16 = 156
-	LGW_FORCE_HOST_RADIO_CTRL               uint16 = 157
-	LGW_FORCE_HOST_FE_CTRL                  uint16 = 158
-	LGW_FORCE_DEC_FILTER_GAIN               uint16 = 159
-	LGW_MCU_RST_0                           uint16 = 160
-	LGW_MCU_RST_1                           uint16 = 161
-	LGW_MCU_SELECT_MUX_0                    uint16 = 162
-	LGW_MCU_SELECT_MUX_1                    uint16 = 163
-	LGW_MCU_CORRUPTION_DETECTED_0           uint16 = 164
-	LGW_MCU_CORRUPTION_DETECTED_1           uint16 = 165
-	LGW_MCU_SELECT_EDGE_0                   uint16 = 166
-	LGW_MCU_SELECT_EDGE_1                   uint16 = 167
-	LGW_CHANN_SELECT_RSSI                   uint16 = 168
-	LGW_RSSI_BB_DEFAULT_VALUE               uint16 = 169
-	LGW_RSSI_DEC_DEFAULT_VALUE              uint16 = 170
-	LGW_RSSI_CHANN_DEFAULT_VALUE            uint16 = 171
-	LGW_RSSI_BB_FILTER_ALPHA                uint16 = 172
-	LGW_RSSI_DEC_FILTER_ALPHA               uint16 = 173
-	LGW_RSSI_CHANN_FILTER_ALPHA             uint16 = 174
-	LGW_IQ_MISMATCH_A_AMP_COEFF             uint16 = 175
-	LGW_IQ_MISMATCH_A_PHI_COEFF             uint16 = 176
-	LGW_IQ_MISMATCH_B_AMP_COEFF             uint16 = 177
-	LGW_IQ_MISMATCH_B_SEL_I                 uint16 = 178
-	LGW_IQ_MISMATCH_B_PHI_COEFF             uint16 = 179
-	LGW_TX_TRIG_IMMEDIATE                   uint16 = 180
-	LGW_TX_TRIG_DELAYED                     uint16 = 181
-	LGW_TX_TRIG_GPS                         uint16 = 182
-	LGW_TX_START_DELAY                      uint16 = 183
-	LGW_TX_FRAME_SYNCH_PEAK1_POS            uint16 = 184
-	LGW_TX_FRAME_SYNCH_PEAK2_POS            uint16 = 185
-	LGW_TX_RAMP_DURATION                    uint16 = 186
-	LGW_TX_OFFSET_I                         uint16 = 187
-	LGW_TX_OFFSET_Q                         uint16 = 188
-	LGW_TX_MODE                             uint16 = 189
-	LGW_TX_ZERO_PAD                         uint16 = 190
-	LGW_TX_EDGE_SELECT                      uint16 = 191
-	LGW_TX_EDGE_SELECT_TOP                  uint16 = 192
-	LGW_TX_GAIN                             uint16 = 193
-	LGW_TX_CHIRP_LOW_PASS                   uint16 = 194
-	LGW_TX_FCC_WIDEBAND                     uint16 = 195
-	LGW_TX_SWAP_IQ                          uint16 = 196
-	LGW_MBWSSF_IMPLICIT_HEADER              uint16 = 197
-	LGW_MBWSSF_IMPLICIT_CRC_EN              uint16 = 198
-	LGW_MBWSSF_IMPLICIT_CODING_RATE         uint16 = 199
-	LGW_MBWSSF_IMPLICIT_PAYLOAD_LENGHT      uint16 = 200
-	LGW_MBWSSF_AGC_FREEZE_ON_DETECT         uint16 = 201
-	LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS        uint16 = 202
-	LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS        uint16 = 203
-	LGW_MBWSSF_PREAMBLE_SYMB1_NB            uint16 = 204
-	LGW_MBWSSF_FRAME_SYNCH_GAIN             uint16 = 205
-	LGW_MBWSSF_SYNCH_DETECT_TH              uint16 = 206
-	LGW_MBWSSF_DETECT_MIN_SINGLE_PEAK       uint16 = 207
-	LGW_MBWSSF_DETECT_TRIG_SAME_PEAK_NB     uint16 = 208
-	LGW_MBWSSF_FREQ_TO_TIME_INVERT          uint16 = 209
-	LGW_MBWSSF_FREQ_TO_TIME_DRIFT           uint16 = 210
-	LGW_MBWSSF_PPM_CORRECTION               uint16 = 211
-	LGW_MBWSSF_PAYLOAD_FINE_TIMING_GAIN     uint16 = 212
-	LGW_MBWSSF_PREAMBLE_FINE_TIMING_GAIN    uint16 = 213
-	LGW_MBWSSF_TRACKING_INTEGRAL            uint16 = 214
-	LGW_MBWSSF_ZERO_PAD                     uint16 = 215
-	LGW_MBWSSF_MODEM_BW                     uint16 = 216
-	LGW_MBWSSF_RADIO_SELECT                 uint16 = 217
-	LGW_MBWSSF_RX_CHIRP_INVERT              uint16 = 218
-	LGW_MBWSSF_LLR_SCALE                    uint16 = 219
-	LGW_MBWSSF_SNR_AVG_CST                  uint16 = 220
-	LGW_MBWSSF_PPM_OFFSET                   uint16 = 221
-	LGW_MBWSSF_RATE_SF                      uint16 = 222
-	LGW_MBWSSF_ONLY_CRC_EN                  uint16 = 223
-	LGW_MBWSSF_MAX_PAYLOAD_LEN              uint16 = 224
-	LGW_TX_STATUS                           uint16 = 225
-	LGW_FSK_CH_BW_EXPO                      uint16 = 226
-	LGW_FSK_RSSI_LENGTH                     uint16 = 227
-	LGW_FSK_RX_INVERT                       uint16 = 228
-	LGW_FSK_PKT_MODE                        uint16 = 229
-	LGW_FSK_PSIZE                           uint16 = 230
-	LGW_FSK_CRC_EN                          uint16 = 231
-	LGW_FSK_DCFREE_ENC                      uint16 = 232
-	LGW_FSK_CRC_IBM                         uint16 = 233
-	LGW_FSK_ERROR_OSR_TOL                   uint16 = 234
-	LGW_FSK_RADIO_SELECT                    uint16 = 235
-	LGW_FSK_BR_RATIO                        uint16 = 236
-	LGW_FSK_REF_PATTERN_LSB                 uint16 = 237
-	LGW_FSK_REF_PATTERN_MSB                 uint16 = 238
-	LGW_FSK_PKT_LENGTH                      uint16 = 239
-	LGW_FSK_TX_GAUSSIAN_EN                  uint16 = 240
-	LGW_FSK_TX_GAUSSIAN_SELECT_BT           uint16 = 241
-	LGW_FSK_TX_PATTERN_EN                   uint16 = 242
-	LGW_FSK_TX_PREAMBLE_SEQ                 uint16 = 243
-	LGW_FSK_TX_PSIZE                        uint16 = 244
-	LGW_FSK_NODE_ADRS                       uint16 = 245
-	LGW_FSK_BROADCAST                       uint16 = 246
-	LGW_FSK_AUTO_AFC_ON                     uint16 = 247
-	LGW_FSK_PATTERN_TIMEOUT_CFG             uint16 = 248
-	LGW_SPI_RADIO_A__DATA                   uint16 = 249
-	LGW_SPI_RADIO_A__DATA_READBACK          uint16 = 250
-	LGW_SPI_RADIO_A__ADDR                   uint16 = 251
-	LGW_SPI_RADIO_A__CS                     uint16 = 252
-	LGW_SPI_RADIO_B__DATA                   uint16 = 253
-	LGW_SPI_RADIO_B__DATA_READBACK          uint16 = 254
-	LGW_SPI_RADIO_B__ADDR                   uint16 = 255
-	LGW_SPI_RADIO_B__CS                     uint16 = 256
-	LGW_RADIO_A_EN                          uint16 = 257
-	LGW_RADIO_B_EN                          uint16 = 258
-	LGW_RADIO_RST                           uint16 = 259
-	LGW_LNA_A_EN                            uint16 = 260
-	LGW_PA_A_EN                             uint16 = 261
-	LGW_LNA_B_EN                            uint16 = 262
-	LGW_PA_B_EN                             uint16 = 263
-	LGW_PA_GAIN                             uint16 = 264
-	LGW_LNA_A_CTRL_LUT                      uint16 = 265
-	LGW_PA_A_CTRL_LUT                       uint16 = 266
-	LGW_LNA_B_CTRL_LUT                      uint16 = 267
-	LGW_PA_B_CTRL_LUT                       uint16 = 268
-	LGW_CAPTURE_SOURCE                      uint16 = 269
-	LGW_CAPTURE_START                       uint16 = 270
-	LGW_CAPTURE_FORCE_TRIGGER               uint16 = 271
-	LGW_CAPTURE_WRAP                        uint16 = 272
-	LGW_CAPTURE_PERIOD                      uint16 = 273
-	LGW_MODEM_STATUS                        uint16 = 274
-	LGW_VALID_HEADER_COUNTER_0              uint16 = 275
-	LGW_VALID_PACKET_COUNTER_0              uint16 = 276
-	LGW_VALID_HEADER_COUNTER_MBWSSF         uint16 = 277
-	LGW_VALID_HEADER_COUNTER_FSK            uint16 = 278
-	LGW_VALID_PACKET_COUNTER_MBWSSF         uint16 = 279
-	LGW_VALID_PACKET_COUNTER_FSK            uint16 = 280
-	LGW_CHANN_RSSI                          uint16 = 281
-	LGW_BB_RSSI                             uint16 = 282
-	LGW_DEC_RSSI                            uint16 = 283
-	LGW_DBG_MCU_DATA                        uint16 = 284
-	LGW_DBG_ARB_MCU_RAM_DATA                uint16 = 285
-	LGW_DBG_AGC_MCU_RAM_DATA                uint16 = 286
-	LGW_NEXT_PACKET_CNT                     uint16 = 287
-	LGW_ADDR_CAPTURE_COUNT                  uint16 = 288
-	LGW_TIMESTAMP                           uint16 = 289
-	LGW_DBG_CHANN0_GAIN                     uint16 = 290
-	LGW_DBG_CHANN1_GAIN                     uint16 = 291
-	LGW_DBG_CHANN2_GAIN                     uint16 = 292
-	LGW_DBG_CHANN3_GAIN                     uint16 = 293
-	LGW_DBG_CHANN4_GAIN                     uint16 = 294
-	LGW_DBG_CHANN5_GAIN                     uint16 = 295
-	LGW_DBG_CHANN6_GAIN                     uint16 = 296
-	LGW_DBG_CHANN7_GAIN                     uint16 = 297
-	LGW_DBG_DEC_FILT_GAIN                   uint16 = 298
-	LGW_SPI_DATA_FIFO_PTR                   uint16 = 299
-	LGW_PACKET_DATA_FIFO_PTR                uint16 = 300
-	LGW_DBG_ARB_MCU_RAM_ADDR                uint16 = 301
-	LGW_DBG_AGC_MCU_RAM_ADDR                uint16 = 302
-	LGW_SPI_MASTER_CHIP_SELECT_POLARITY     uint16 = 303
-	LGW_SPI_MASTER_CPOL                     uint16 = 304
-	LGW_SPI_MASTER_CPHA                     uint16 = 305
-	LGW_SIG_GEN_ANALYSER_MUX_SEL            uint16 = 306
-	LGW_SIG_GEN_EN                          uint16 = 307
-	LGW_SIG_ANALYSER_EN                     uint16 = 308
-	LGW_SIG_ANALYSER_AVG_LEN                uint16 = 309
-	LGW_SIG_ANALYSER_PRECISION              uint16 = 310
-	LGW_SIG_ANALYSER_VALID_OUT              uint16 = 311
-	LGW_SIG_GEN_FREQ                        uint16 = 312
-	LGW_SIG_ANALYSER_FREQ                   uint16 = 313
-	LGW_SIG_ANALYSER_I_OUT                  uint16 = 314
-	LGW_SIG_ANALYSER_Q_OUT                  uint16 = 315
-	LGW_GPS_EN                              uint16 = 316
-	LGW_GPS_POL                             uint16 = 317
-	LGW_SW_TEST_REG1                        uint16 = 318
-	LGW_SW_TEST_REG2                        uint16 = 319
-	LGW_SW_TEST_REG3                        uint16 = 320
-	LGW_DATA_MNGT_STATUS                    uint16 = 321
-	LGW_DATA_MNGT_CPT_FRAME_ALLOCATED       uint16 = 322
-	LGW_DATA_MNGT_CPT_FRAME_FINISHED        uint16 = 323
-	LGW_DATA_MNGT_CPT_FRAME_READEN          uint16 = 324
-	LGW_TX_TRIG_ALL                         uint16 = 325
-	LGW_TOTALREGS                           uint16 = 326
+	LGW_PAGE_REG                            Lgw_reg_id = 0
+	LGW_SOFT_RESET                          Lgw_reg_id = 1
+	LGW_VERSION                             Lgw_reg_id = 2
+	LGW_RX_DATA_BUF_ADDR                    Lgw_reg_id = 3
+	LGW_RX_DATA_BUF_DATA                    Lgw_reg_id = 4
+	LGW_TX_DATA_BUF_ADDR                    Lgw_reg_id = 5
+	LGW_TX_DATA_BUF_DATA                    Lgw_reg_id = 6
+	LGW_CAPTURE_RAM_ADDR                    Lgw_reg_id = 7
+	LGW_CAPTURE_RAM_DATA                    Lgw_reg_id = 8
+	LGW_MCU_PROM_ADDR                       Lgw_reg_id = 9
+	LGW_MCU_PROM_DATA                       Lgw_reg_id = 10
+	LGW_RX_PACKET_DATA_FIFO_NUM_STORED      Lgw_reg_id = 11
+	LGW_RX_PACKET_DATA_FIFO_ADDR_POINTER    Lgw_reg_id = 12
+	LGW_RX_PACKET_DATA_FIFO_STATUS          Lgw_reg_id = 13
+	LGW_RX_PACKET_DATA_FIFO_PAYLOAD_SIZE    Lgw_reg_id = 14
+	LGW_MBWSSF_MODEM_ENABLE                 Lgw_reg_id = 15
+	LGW_CONCENTRATOR_MODEM_ENABLE           Lgw_reg_id = 16
+	LGW_FSK_MODEM_ENABLE                    Lgw_reg_id = 17
+	LGW_GLOBAL_EN                           Lgw_reg_id = 18
+	LGW_CLK32M_EN                           Lgw_reg_id = 19
+	LGW_CLKHS_EN                            Lgw_reg_id = 20
+	LGW_START_BIST0                         Lgw_reg_id = 21
+	LGW_START_BIST1                         Lgw_reg_id = 22
+	LGW_CLEAR_BIST0                         Lgw_reg_id = 23
+	LGW_CLEAR_BIST1                         Lgw_reg_id = 24
+	LGW_BIST0_FINISHED                      Lgw_reg_id = 25
+	LGW_BIST1_FINISHED                      Lgw_reg_id = 26
+	LGW_MCU_AGC_PROG_RAM_BIST_STATUS        Lgw_reg_id = 27
+	LGW_MCU_ARB_PROG_RAM_BIST_STATUS        Lgw_reg_id = 28
+	LGW_CAPTURE_RAM_BIST_STATUS             Lgw_reg_id = 29
+	LGW_CHAN_FIR_RAM0_BIST_STATUS           Lgw_reg_id = 30
+	LGW_CHAN_FIR_RAM1_BIST_STATUS           Lgw_reg_id = 31
+	LGW_CORR0_RAM_BIST_STATUS               Lgw_reg_id = 32
+	LGW_CORR1_RAM_BIST_STATUS               Lgw_reg_id = 33
+	LGW_CORR2_RAM_BIST_STATUS               Lgw_reg_id = 34
+	LGW_CORR3_RAM_BIST_STATUS               Lgw_reg_id = 35
+	LGW_CORR4_RAM_BIST_STATUS               Lgw_reg_id = 36
+	LGW_CORR5_RAM_BIST_STATUS               Lgw_reg_id = 37
+	LGW_CORR6_RAM_BIST_STATUS               Lgw_reg_id = 38
+	LGW_CORR7_RAM_BIST_STATUS               Lgw_reg_id = 39
+	LGW_MODEM0_RAM0_BIST_STATUS             Lgw_reg_id = 40
+	LGW_MODEM1_RAM0_BIST_STATUS             Lgw_reg_id = 41
+	LGW_MODEM2_RAM0_BIST_STATUS             Lgw_reg_id = 42
+	LGW_MODEM3_RAM0_BIST_STATUS             Lgw_reg_id = 43
+	LGW_MODEM4_RAM0_BIST_STATUS             Lgw_reg_id = 44
+	LGW_MODEM5_RAM0_BIST_STATUS             Lgw_reg_id = 45
+	LGW_MODEM6_RAM0_BIST_STATUS             Lgw_reg_id = 46
+	LGW_MODEM7_RAM0_BIST_STATUS             Lgw_reg_id = 47
+	LGW_MODEM0_RAM1_BIST_STATUS             Lgw_reg_id = 48
+	LGW_MODEM1_RAM1_BIST_STATUS             Lgw_reg_id = 49
+	LGW_MODEM2_RAM1_BIST_STATUS             Lgw_reg_id = 50
+	LGW_MODEM3_RAM1_BIST_STATUS             Lgw_reg_id = 51
+	LGW_MODEM4_RAM1_BIST_STATUS             Lgw_reg_id = 52
+	LGW_MODEM5_RAM1_BIST_STATUS             Lgw_reg_id = 53
+	LGW_MODEM6_RAM1_BIST_STATUS             Lgw_reg_id = 54
+	LGW_MODEM7_RAM1_BIST_STATUS             Lgw_reg_id = 55
+	LGW_MODEM0_RAM2_BIST_STATUS             Lgw_reg_id = 56
+	LGW_MODEM1_RAM2_BIST_STATUS             Lgw_reg_id = 57
+	LGW_MODEM2_RAM2_BIST_STATUS             Lgw_reg_id = 58
+	LGW_MODEM3_RAM2_BIST_STATUS             Lgw_reg_id = 59
+	LGW_MODEM4_RAM2_BIST_STATUS             Lgw_reg_id = 60
+	LGW_MODEM5_RAM2_BIST_STATUS             Lgw_reg_id = 61
+	LGW_MODEM6_RAM2_BIST_STATUS             Lgw_reg_id = 62
+	LGW_MODEM7_RAM2_BIST_STATUS             Lgw_reg_id = 63
+	LGW_MODEM_MBWSSF_RAM0_BIST_STATUS       Lgw_reg_id = 64
+	LGW_MODEM_MBWSSF_RAM1_BIST_STATUS       Lgw_reg_id = 65
+	LGW_MODEM_MBWSSF_RAM2_BIST_STATUS       Lgw_reg_id = 66
+	LGW_MCU_AGC_DATA_RAM_BIST0_STATUS       Lgw_reg_id = 67
+	LGW_MCU_AGC_DATA_RAM_BIST1_STATUS       Lgw_reg_id = 68
+	LGW_MCU_ARB_DATA_RAM_BIST0_STATUS       Lgw_reg_id = 69
+	LGW_MCU_ARB_DATA_RAM_BIST1_STATUS       Lgw_reg_id = 70
+	LGW_TX_TOP_RAM_BIST0_STATUS             Lgw_reg_id = 71
+	LGW_TX_TOP_RAM_BIST1_STATUS             Lgw_reg_id = 72
+	LGW_DATA_MNGT_RAM_BIST0_STATUS          Lgw_reg_id = 73
+	LGW_DATA_MNGT_RAM_BIST1_STATUS          Lgw_reg_id = 74
+	LGW_GPIO_SELECT_INPUT                   Lgw_reg_id = 75
+	LGW_GPIO_SELECT_OUTPUT                  Lgw_reg_id = 76
+	LGW_GPIO_MODE                           Lgw_reg_id = 77
+	LGW_GPIO_PIN_REG_IN                     Lgw_reg_id = 78
+	LGW_GPIO_PIN_REG_OUT                    Lgw_reg_id = 79
+	LGW_MCU_AGC_STATUS                      Lgw_reg_id = 80
+	LGW_MCU_ARB_STATUS                      Lgw_reg_id = 81
+	LGW_CHIP_ID                             Lgw_reg_id = 82
+	LGW_EMERGENCY_FORCE_HOST_CTRL           Lgw_reg_id = 83
+	LGW_RX_INVERT_IQ                        Lgw_reg_id = 84
+	LGW_MODEM_INVERT_IQ                     Lgw_reg_id = 85
+	LGW_MBWSSF_MODEM_INVERT_IQ              Lgw_reg_id = 86
+	LGW_RX_EDGE_SELECT                      Lgw_reg_id = 87
+	LGW_MISC_RADIO_EN                       Lgw_reg_id = 88
+	LGW_FSK_MODEM_INVERT_IQ                 Lgw_reg_id = 89
+	LGW_FILTER_GAIN                         Lgw_reg_id = 90
+	LGW_RADIO_SELECT                        Lgw_reg_id = 91
+	LGW_IF_FREQ_0                           Lgw_reg_id = 92
+	LGW_IF_FREQ_1                           Lgw_reg_id = 93
+	LGW_IF_FREQ_2                           Lgw_reg_id = 94
+	LGW_IF_FREQ_3                           Lgw_reg_id = 95
+	LGW_IF_FREQ_4                           Lgw_reg_id = 96
+	LGW_IF_FREQ_5                           Lgw_reg_id = 97
+	LGW_IF_FREQ_6                           Lgw_reg_id = 98
+	LGW_IF_FREQ_7                           Lgw_reg_id = 99
+	LGW_IF_FREQ_8                           Lgw_reg_id = 100
+	LGW_IF_FREQ_9                           Lgw_reg_id = 101
+	LGW_CHANN_OVERRIDE_AGC_GAIN             Lgw_reg_id = 102
+	LGW_CHANN_AGC_GAIN                      Lgw_reg_id = 103
+	LGW_CORR0_DETECT_EN                     Lgw_reg_id = 104
+	LGW_CORR1_DETECT_EN                     Lgw_reg_id = 105
+	LGW_CORR2_DETECT_EN                     Lgw_reg_id = 106
+	LGW_CORR3_DETECT_EN                     Lgw_reg_id = 107
+	LGW_CORR4_DETECT_EN                     Lgw_reg_id = 108
+	LGW_CORR5_DETECT_EN                     Lgw_reg_id = 109
+	LGW_CORR6_DETECT_EN                     Lgw_reg_id = 110
+	LGW_CORR7_DETECT_EN                     Lgw_reg_id = 111
+	LGW_CORR_SAME_PEAKS_OPTION_SF6          Lgw_reg_id = 112
+	LGW_CORR_SAME_PEAKS_OPTION_SF7          Lgw_reg_id = 113
+	LGW_CORR_SAME_PEAKS_OPTION_SF8          Lgw_reg_id = 114
+	LGW_CORR_SAME_PEAKS_OPTION_SF9          Lgw_reg_id = 115
+	LGW_CORR_SAME_PEAKS_OPTION_SF10         Lgw_reg_id = 116
+	LGW_CORR_SAME_PEAKS_OPTION_SF11         Lgw_reg_id = 117
+	LGW_CORR_SAME_PEAKS_OPTION_SF12         Lgw_reg_id = 118
+	LGW_CORR_SIG_NOISE_RATIO_SF6            Lgw_reg_id = 119
+	LGW_CORR_SIG_NOISE_RATIO_SF7            Lgw_reg_id = 120
+	LGW_CORR_SIG_NOISE_RATIO_SF8            Lgw_reg_id = 121
+	LGW_CORR_SIG_NOISE_RATIO_SF9            Lgw_reg_id = 122
+	LGW_CORR_SIG_NOISE_RATIO_SF10           Lgw_reg_id = 123
+	LGW_CORR_SIG_NOISE_RATIO_SF11           Lgw_reg_id = 124
+	LGW_CORR_SIG_NOISE_RATIO_SF12           Lgw_reg_id = 125
+	LGW_CORR_NUM_SAME_PEAK                  Lgw_reg_id = 126
+	LGW_CORR_MAC_GAIN                       Lgw_reg_id = 127
+	LGW_ADJUST_MODEM_START_OFFSET_RDX4      Lgw_reg_id = 128
+	LGW_ADJUST_MODEM_START_OFFSET_SF12_RDX4 Lgw_reg_id = 129
+	LGW_DBG_CORR_SELECT_SF                  Lgw_reg_id = 130
+	LGW_DBG_CORR_SELECT_CHANNEL             Lgw_reg_id = 131
+	LGW_DBG_DETECT_CPT                      Lgw_reg_id = 132
+	LGW_DBG_SYMB_CPT                        Lgw_reg_id = 133
+	LGW_CHIRP_INVERT_RX                     Lgw_reg_id = 134
+	LGW_DC_NOTCH_EN                         Lgw_reg_id = 135
+	LGW_IMPLICIT_CRC_EN                     Lgw_reg_id = 136
+	LGW_IMPLICIT_CODING_RATE                Lgw_reg_id = 137
+	LGW_IMPLICIT_PAYLOAD_LENGHT             Lgw_reg_id = 138
+	LGW_FREQ_TO_TIME_INVERT                 Lgw_reg_id = 139
+	LGW_FREQ_TO_TIME_DRIFT                  Lgw_reg_id = 140
+	LGW_PAYLOAD_FINE_TIMING_GAIN            Lgw_reg_id = 141
+	LGW_PREAMBLE_FINE_TIMING_GAIN           Lgw_reg_id = 142
+	LGW_TRACKING_INTEGRAL                   Lgw_reg_id = 143
+	LGW_FRAME_SYNCH_PEAK1_POS               Lgw_reg_id = 144
+	LGW_FRAME_SYNCH_PEAK2_POS               Lgw_reg_id = 145
+	LGW_PREAMBLE_SYMB1_NB                   Lgw_reg_id = 146
+	LGW_FRAME_SYNCH_GAIN                    Lgw_reg_id = 147
+	LGW_SYNCH_DETECT_TH                     Lgw_reg_id = 148
+	LGW_LLR_SCALE                           Lgw_reg_id = 149
+	LGW_SNR_AVG_CST                         Lgw_reg_id = 150
+	LGW_PPM_OFFSET                          Lgw_reg_id = 151
+	LGW_MAX_PAYLOAD_LEN                     Lgw_reg_id = 152
+	LGW_ONLY_CRC_EN                         Lgw_reg_id = 153
+	LGW_ZERO_PAD                            Lgw_reg_id = 154
+	LGW_DEC_GAIN_OFFSET                     Lgw_reg_id = 155
+	LGW_CHAN_GAIN_OFFSET                    Lgw_reg_id = 156
+	LGW_FORCE_HOST_RADIO_CTRL               Lgw_reg_id = 157
+	LGW_FORCE_HOST_FE_CTRL                  Lgw_reg_id = 158
+	LGW_FORCE_DEC_FILTER_GAIN               Lgw_reg_id = 159
+	LGW_MCU_RST_0                           Lgw_reg_id = 160
+	LGW_MCU_RST_1                           Lgw_reg_id = 161
+	LGW_MCU_SELECT_MUX_0                    Lgw_reg_id = 162
+	LGW_MCU_SELECT_MUX_1                    Lgw_reg_id = 163
+	LGW_MCU_CORRUPTION_DETECTED_0           Lgw_reg_id = 164
+	LGW_MCU_CORRUPTION_DETECTED_1           Lgw_reg_id = 165
+	LGW_MCU_SELECT_EDGE_0                   Lgw_reg_id = 166
+	LGW_MCU_SELECT_EDGE_1                   Lgw_reg_id = 167
+	LGW_CHANN_SELECT_RSSI                   Lgw_reg_id = 168
+	LGW_RSSI_BB_DEFAULT_VALUE               Lgw_reg_id = 169
+	LGW_RSSI_DEC_DEFAULT_VALUE              Lgw_reg_id = 170
+	LGW_RSSI_CHANN_DEFAULT_VALUE            Lgw_reg_id = 171
+	LGW_RSSI_BB_FILTER_ALPHA                Lgw_reg_id = 172
+	LGW_RSSI_DEC_FILTER_ALPHA               Lgw_reg_id = 173
+	LGW_RSSI_CHANN_FILTER_ALPHA             Lgw_reg_id = 174
+	LGW_IQ_MISMATCH_A_AMP_COEFF             Lgw_reg_id = 175
+	LGW_IQ_MISMATCH_A_PHI_COEFF             Lgw_reg_id = 176
+	LGW_IQ_MISMATCH_B_AMP_COEFF             Lgw_reg_id = 177
+	LGW_IQ_MISMATCH_B_SEL_I                 Lgw_reg_id = 178
+	LGW_IQ_MISMATCH_B_PHI_COEFF             Lgw_reg_id = 179
+	LGW_TX_TRIG_IMMEDIATE                   Lgw_reg_id = 180
+	LGW_TX_TRIG_DELAYED                     Lgw_reg_id = 181
+	LGW_TX_TRIG_GPS                         Lgw_reg_id = 182
+	LGW_TX_START_DELAY                      Lgw_reg_id = 183
+	LGW_TX_FRAME_SYNCH_PEAK1_POS            Lgw_reg_id = 184
+	LGW_TX_FRAME_SYNCH_PEAK2_POS            Lgw_reg_id = 185
+	LGW_TX_RAMP_DURATION                    Lgw_reg_id = 186
+	LGW_TX_OFFSET_I                         Lgw_reg_id = 187
+	LGW_TX_OFFSET_Q                         Lgw_reg_id = 188
+	LGW_TX_MODE                             Lgw_reg_id = 189
+	LGW_TX_ZERO_PAD                         Lgw_reg_id = 190
+	LGW_TX_EDGE_SELECT                      Lgw_reg_id = 191
+	LGW_TX_EDGE_SELECT_TOP                  Lgw_reg_id = 192
+	LGW_TX_GAIN                             Lgw_reg_id = 193
+	LGW_TX_CHIRP_LOW_PASS                   Lgw_reg_id = 194
+	LGW_TX_FCC_WIDEBAND                     Lgw_reg_id = 195
+	LGW_TX_SWAP_IQ                          Lgw_reg_id = 196
+	LGW_MBWSSF_IMPLICIT_HEADER              Lgw_reg_id = 197
+	LGW_MBWSSF_IMPLICIT_CRC_EN              Lgw_reg_id = 198
+	LGW_MBWSSF_IMPLICIT_CODING_RATE         Lgw_reg_id = 199
+	LGW_MBWSSF_IMPLICIT_PAYLOAD_LENGHT      Lgw_reg_id = 200
+	LGW_MBWSSF_AGC_FREEZE_ON_DETECT         Lgw_reg_id = 201
+	LGW_MBWSSF_FRAME_SYNCH_PEAK1_POS        Lgw_reg_id = 202
+	LGW_MBWSSF_FRAME_SYNCH_PEAK2_POS        Lgw_reg_id = 203
+	LGW_MBWSSF_PREAMBLE_SYMB1_NB            Lgw_reg_id = 204
+	LGW_MBWSSF_FRAME_SYNCH_GAIN             Lgw_reg_id = 205
+	LGW_MBWSSF_SYNCH_DETECT_TH              Lgw_reg_id = 206
+	LGW_MBWSSF_DETECT_MIN_SINGLE_PEAK       Lgw_reg_id = 207
+	LGW_MBWSSF_DETECT_TRIG_SAME_PEAK_NB     Lgw_reg_id = 208
+	LGW_MBWSSF_FREQ_TO_TIME_INVERT          Lgw_reg_id = 209
+	LGW_MBWSSF_FREQ_TO_TIME_DRIFT           Lgw_reg_id = 210
+	LGW_MBWSSF_PPM_CORRECTION               Lgw_reg_id = 211
+	LGW_MBWSSF_PAYLOAD_FINE_TIMING_GAIN     Lgw_reg_id = 212
+	LGW_MBWSSF_PREAMBLE_FINE_TIMING_GAIN    Lgw_reg_id = 213
+	LGW_MBWSSF_TRACKING_INTEGRAL            Lgw_reg_id = 214
+	LGW_MBWSSF_ZERO_PAD                     Lgw_reg_id = 215
+	LGW_MBWSSF_MODEM_BW                     Lgw_reg_id = 216
+	LGW_MBWSSF_RADIO_SELECT                 Lgw_reg_id = 217
+	LGW_MBWSSF_RX_CHIRP_INVERT              Lgw_reg_id = 218
+	LGW_MBWSSF_LLR_SCALE                    Lgw_reg_id = 219
+	LGW_MBWSSF_SNR_AVG_CST                  Lgw_reg_id = 220
+	LGW_MBWSSF_PPM_OFFSET                   Lgw_reg_id = 221
+	LGW_MBWSSF_RATE_SF                      Lgw_reg_id = 222
+	LGW_MBWSSF_ONLY_CRC_EN                  Lgw_reg_id = 223
+	LGW_MBWSSF_MAX_PAYLOAD_LEN              Lgw_reg_id = 224
+	LGW_TX_STATUS                           Lgw_reg_id = 225
+	LGW_FSK_CH_BW_EXPO                      Lgw_reg_id = 226
+	LGW_FSK_RSSI_LENGTH                     Lgw_reg_id = 227
+	LGW_FSK_RX_INVERT                       Lgw_reg_id = 228
+	LGW_FSK_PKT_MODE                        Lgw_reg_id = 229
+	LGW_FSK_PSIZE                           Lgw_reg_id = 230
+	LGW_FSK_CRC_EN                          Lgw_reg_id = 231
+	LGW_FSK_DCFREE_ENC                      Lgw_reg_id = 232
+	LGW_FSK_CRC_IBM                         Lgw_reg_id = 233
+	LGW_FSK_ERROR_OSR_TOL                   Lgw_reg_id = 234
+	LGW_FSK_RADIO_SELECT                    Lgw_reg_id = 235
+	LGW_FSK_BR_RATIO                        Lgw_reg_id = 236
+	LGW_FSK_REF_PATTERN_LSB                 Lgw_reg_id = 237
+	LGW_FSK_REF_PATTERN_MSB                 Lgw_reg_id = 238
+	LGW_FSK_PKT_LENGTH                      Lgw_reg_id = 239
+	LGW_FSK_TX_GAUSSIAN_EN                  Lgw_reg_id = 240
+	LGW_FSK_TX_GAUSSIAN_SELECT_BT           Lgw_reg_id = 241
+	LGW_FSK_TX_PATTERN_EN                   Lgw_reg_id = 242
+	LGW_FSK_TX_PREAMBLE_SEQ                 Lgw_reg_id = 243
+	LGW_FSK_TX_PSIZE                        Lgw_reg_id = 244
+	LGW_FSK_NODE_ADRS                       Lgw_reg_id = 245
+	LGW_FSK_BROADCAST                       Lgw_reg_id = 246
+	LGW_FSK_AUTO_AFC_ON                     Lgw_reg_id = 247
+	LGW_FSK_PATTERN_TIMEOUT_CFG             Lgw_reg_id = 248
+	LGW_SPI_RADIO_A__DATA                   Lgw_reg_id = 249
+	LGW_SPI_RADIO_A__DATA_READBACK          Lgw_reg_id = 250
+	LGW_SPI_RADIO_A__ADDR                   Lgw_reg_id = 251
+	LGW_SPI_RADIO_A__CS                     Lgw_reg_id = 252
+	LGW_SPI_RADIO_B__DATA                   Lgw_reg_id = 253
+	LGW_SPI_RADIO_B__DATA_READBACK          Lgw_reg_id = 254
+	LGW_SPI_RADIO_B__ADDR                   Lgw_reg_id = 255
+	LGW_SPI_RADIO_B__CS                     Lgw_reg_id = 256
+	LGW_RADIO_A_EN                          Lgw_reg_id = 257
+	LGW_RADIO_B_EN                          Lgw_reg_id = 258
+	LGW_RADIO_RST                           Lgw_reg_id = 259
+	LGW_LNA_A_EN                            Lgw_reg_id = 260
+	LGW_PA_A_EN                             Lgw_reg_id = 261
+	LGW_LNA_B_EN                            Lgw_reg_id = 262
+	LGW_PA_B_EN                             Lgw_reg_id = 263
+	LGW_PA_GAIN                             Lgw_reg_id = 264
+	LGW_LNA_A_CTRL_LUT                      Lgw_reg_id = 265
+	LGW_PA_A_CTRL_LUT                       Lgw_reg_id = 266
+	LGW_LNA_B_CTRL_LUT                      Lgw_reg_id = 267
+	LGW_PA_B_CTRL_LUT                       Lgw_reg_id = 268
+	LGW_CAPTURE_SOURCE                      Lgw_reg_id = 269
+	LGW_CAPTURE_START                       Lgw_reg_id = 270
+	LGW_CAPTURE_FORCE_TRIGGER               Lgw_reg_id = 271
+	LGW_CAPTURE_WRAP                        Lgw_reg_id = 272
+	LGW_CAPTURE_PERIOD                      Lgw_reg_id = 273
+	LGW_MODEM_STATUS                        Lgw_reg_id = 274
+	LGW_VALID_HEADER_COUNTER_0              Lgw_reg_id = 275
+	LGW_VALID_PACKET_COUNTER_0              Lgw_reg_id = 276
+	LGW_VALID_HEADER_COUNTER_MBWSSF         Lgw_reg_id = 277
+	LGW_VALID_HEADER_COUNTER_FSK            Lgw_reg_id = 278
+	LGW_VALID_PACKET_COUNTER_MBWSSF         Lgw_reg_id = 279
+	LGW_VALID_PACKET_COUNTER_FSK            Lgw_reg_id = 280
+	LGW_CHANN_RSSI                          Lgw_reg_id = 281
+	LGW_BB_RSSI                             Lgw_reg_id = 282
+	LGW_DEC_RSSI                            Lgw_reg_id = 283
+	LGW_DBG_MCU_DATA                        Lgw_reg_id = 284
+	LGW_DBG_ARB_MCU_RAM_DATA                Lgw_reg_id = 285
+	LGW_DBG_AGC_MCU_RAM_DATA                Lgw_reg_id = 286
+	LGW_NEXT_PACKET_CNT                     Lgw_reg_id = 287
+	LGW_ADDR_CAPTURE_COUNT                  Lgw_reg_id = 288
+	LGW_TIMESTAMP                           Lgw_reg_id = 289
+	LGW_DBG_CHANN0_GAIN                     Lgw_reg_id = 290
+	LGW_DBG_CHANN1_GAIN                     Lgw_reg_id = 291
+	LGW_DBG_CHANN2_GAIN                     Lgw_reg_id = 292
+	LGW_DBG_CHANN3_GAIN                     Lgw_reg_id = 293
+	LGW_DBG_CHANN4_GAIN                     Lgw_reg_id = 294
+	LGW_DBG_CHANN5_GAIN                     Lgw_reg_id = 295
+	LGW_DBG_CHANN6_GAIN                     Lgw_reg_id = 296
+	LGW_DBG_CHANN7_GAIN                     Lgw_reg_id = 297
+	LGW_DBG_DEC_FILT_GAIN                   Lgw_reg_id = 298
+	LGW_SPI_DATA_FIFO_PTR                   Lgw_reg_id = 299
+	LGW_PACKET_DATA_FIFO_PTR                Lgw_reg_id = 300
+	LGW_DBG_ARB_MCU_RAM_ADDR                Lgw_reg_id = 301
+	LGW_DBG_AGC_MCU_RAM_ADDR                Lgw_reg_id = 302
+	LGW_SPI_MASTER_CHIP_SELECT_POLARITY     Lgw_reg_id = 303
+	LGW_SPI_MASTER_CPOL                     Lgw_reg_id = 304
+	LGW_SPI_MASTER_CPHA                     Lgw_reg_id = 305
+	LGW_SIG_GEN_ANALYSER_MUX_SEL            Lgw_reg_id = 306
+	LGW_SIG_GEN_EN                          Lgw_reg_id = 307
+	LGW_SIG_ANALYSER_EN                     Lgw_reg_id = 308
+	LGW_SIG_ANALYSER_AVG_LEN                Lgw_reg_id = 309
+	LGW_SIG_ANALYSER_PRECISION              Lgw_reg_id = 310
+	LGW_SIG_ANALYSER_VALID_OUT              Lgw_reg_id = 311
+	LGW_SIG_GEN_FREQ                        Lgw_reg_id = 312
+	LGW_SIG_ANALYSER_FREQ                   Lgw_reg_id = 313
+	LGW_SIG_ANALYSER_I_OUT                  Lgw_reg_id = 314
+	LGW_SIG_ANALYSER_Q_OUT                  Lgw_reg_id = 315
+	LGW_GPS_EN                              Lgw_reg_id = 316
+	LGW_GPS_POL                             Lgw_reg_id = 317
+	LGW_SW_TEST_REG1                        Lgw_reg_id = 318
+	LGW_SW_TEST_REG2                        Lgw_reg_id = 319
+	LGW_SW_TEST_REG3                        Lgw_reg_id = 320
+	LGW_DATA_MNGT_STATUS                    Lgw_reg_id = 321
+	LGW_DATA_MNGT_CPT_FRAME_ALLOCATED       Lgw_reg_id = 322
+	LGW_DATA_MNGT_CPT_FRAME_FINISHED        Lgw_reg_id = 323
+	LGW_DATA_MNGT_CPT_FRAME_READEN          Lgw_reg_id = 324
+	LGW_TX_TRIG_ALL                         Lgw_reg_id = 325
+	LGW_TOTALREGS                           Lgw_reg_id = 326
 )
 
-func Page_switch(c *os.File, lgw_spi_mux_mode, target byte) error {
+func Page_switch(c *Concentrator, target byte) error {
 	lgw_regpage := PAGE_MASK & target
-	err := Lgw_spi_w(c, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, PAGE_ADDR, lgw_regpage)
+	/* skip the write if the page is already selected, the MCU may switch pages while it controls the registers */
+	if !c.mcu_reg_ctrl && (c.page == int8(lgw_regpage)) {
+		return nil
+	}
+	err := c.transport.Spi_w(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, PAGE_ADDR, lgw_regpage)
 	if err != nil {
+		c.page = -1
 		return err
 	}
+	c.page = int8(lgw_regpage)
 	return nil
 }
 
@@ -700,18 +711,33 @@ func Check_fpga_version(version byte) bool {
 	return false
 }
 
-func reg_w_align32(c *os.File, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s, reg_value int32) error {
+/* reg_tail_shared tells if the last byte of r holds bits of another register of regs */
+func reg_tail_shared(regs []Lgw_reg_s, r Lgw_reg_s) bool {
+	last := uint16(r.addr) + (uint16(r.offs)+uint16(r.leng)+7)/8 - 1
+	for _, q := range regs {
+		if (q == r) || ((q.page != r.page) && (q.page != -1) && (r.page != -1)) {
+			continue
+		}
+		if (uint16(q.addr) <= last) && (last < uint16(q.addr)+(uint16(q.offs)+uint16(q.leng)+7)/8) {
+			return true
+		}
+	}
+	return false
+}
+
+/* reg_w_align32 writes r, one of the registers of regs */
+func reg_w_align32(t Transport, spi_mux_mode, spi_mux_target byte, regs []Lgw_reg_s, r Lgw_reg_s, reg_value int32) error {
 
 	buf := make([]byte, 4)
 	if (r.leng == 8) && (r.offs == 0) {
 		/* direct write */
-		err := Lgw_spi_w(c, spi_mux_mode, spi_mux_target, r.addr, byte(reg_value))
+		err := t.Spi_w(spi_mux_mode, spi_mux_target, r.addr, byte(reg_value))
 		if err != nil {
 			return err
 		}
 	} else if (r.offs + r.leng) <= 8 {
 		/* single-byte read-modify-write, offs:[0-7], leng:[1-7] */
-		b, err := Lgw_spi_r(c, spi_mux_mode, spi_mux_target, r.addr)
+		b, err := t.Spi_r(spi_mux_mode, spi_mux_target, r.addr)
 		if err != nil {
 			return err
 		}
@@ -719,12 +745,12 @@ func reg_w_align32(c *os.File, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s, r
 		buf[1] = ((1 << r.leng) - 1) << r.offs            /* bit mask */
 		buf[2] = (byte(reg_value)) << r.offs              /* new data offsetted */
 		buf[3] = (^(buf[1]) & buf[0]) | (buf[1] & buf[2]) /* mixing old & new data */
-		err = Lgw_spi_w(c, spi_mux_mode, spi_mux_target, r.addr, buf[3])
+		err = t.Spi_w(spi_mux_mode, spi_mux_target, r.addr, buf[3])
 		if err != nil {
 			return err
 		}
-	} else if (r.offs == 0) && (r.leng > 0) && (r.leng <= 32) {
-		/* multi-byte direct write routine */
+	} else if (r.offs == 0) && (r.leng <= 32) && ((r.leng%8 == 0) || !reg_tail_shared(regs, r)) {
+		/* multi-byte direct write routine, the unused bits of a last byte no other register uses get the sign bits */
 		size_byte := (r.leng + 7) / 8 /* add a byte if it's not an exact multiple of 8 */
 
 		for i := 0; i < int(size_byte); i++ {
@@ -733,24 +759,41 @@ func reg_w_align32(c *os.File, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s, r
 			buf[i] = byte(0x000000FF & reg_value)
 			reg_value = (reg_value >> 8)
 		}
-		err := Lgw_spi_wb(c, spi_mux_mode, spi_mux_target, r.addr, buf[0:size_byte]) /* write the register in one burst */
+		err := t.Spi_wb(spi_mux_mode, spi_mux_target, r.addr, buf[0:size_byte]) /* write the register in one burst */
+		if err != nil {
+			return err
+		}
+	} else if (r.leng > 0) && (r.leng <= 32) {
+		/* multi-byte read-modify-write, the bytes at either end may be shared with other registers */
+		size_byte := (uint16(r.offs) + uint16(r.leng) + 7) / 8
+		b, err := t.Spi_rb(spi_mux_mode, spi_mux_target, r.addr, size_byte)
+		if err != nil {
+			return err
+		}
+		old := reg_bytes_to_u64(b)
+		mask := ((uint64(1) << r.leng) - 1) << r.offs
+		u := (old &^ mask) | ((uint64(uint32(reg_value)) << r.offs) & mask) /* mixing old & new data */
+		for i := range b {
+			b[i] = byte(u)
+			u = u >> 8
+		}
+		err = t.Spi_wb(spi_mux_mode, spi_mux_target, r.addr, b)
 		if err != nil {
 			return err
 		}
 	} else {
-		/* register spanning multiple memory bytes but with an offset */
-		return fmt.Errorf("ERROR: REGISTER SIZE AND OFFSET ARE NOT SUPPORTED\n")
+		return fmt.Errorf("ERROR: REGISTER SIZE IS NOT SUPPORTED\n")
 	}
 	return nil
 }
 
-func reg_r_align32(c *os.File, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s) (int32, error) {
+func reg_r_align32(t Transport, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s) (int32, error) {
 	bufu := make([]byte, 4)
 
 	if (r.offs + r.leng) <= 8 {
 		/* read one byte, then shift and mask bits to get reg value with sign extension if needed */
 
-		b, err := Lgw_spi_r(c, spi_mux_mode, spi_mux_target, r.addr)
+		b, err := t.Spi_r(spi_mux_mode, spi_mux_target, r.addr)
 		if err != nil {
 			return 0, err
 		}
@@ -763,136 +806,343 @@ func reg_r_align32(c *os.File, spi_mux_mode, spi_mux_target byte, r Lgw_reg_s) (
 			bufu[2] = bufu[1] >> (8 - r.leng) /* right align the data, no sign extension */
 			return int32(bufu[2]), nil        /* unsigned pointer -> no sign extension */
 		}
-	} else if (r.offs == 0) && (r.leng > 0) && (r.leng <= 32) {
-		size_byte := int((r.leng)+7) / 8 /* add a byte if it's not an exact multiple of 8 */
-		bufu, err := Lgw_spi_rb(c, spi_mux_mode, spi_mux_target, r.addr, uint16(size_byte))
+	} else if (r.leng > 0) && (r.leng <= 32) {
+		/* multi-byte read, then shift and mask bits to get reg value with sign extension if needed */
+		size_byte := (uint16(r.offs) + uint16(r.leng) + 7) / 8 /* add a byte if it's not an exact multiple of 8 */
+		bufu, err := t.Spi_rb(spi_mux_mode, spi_mux_target, r.addr, size_byte)
 		if err != nil {
 			return 0, err
 		}
-		var u uint32
-		for i := (size_byte - 1); i >= 0; i-- {
-			u = uint32(bufu[i]) + (u << 8) /* transform a 4-byte array into a 32 bit word */
-		}
+		u := reg_bytes_to_u64(bufu) << (64 - r.leng - r.offs) /* left-align the data */
 		if r.sign == 1 {
-			u = u << (32 - r.leng)                /* left-align the data */
-			return int32(u) >> (32 - r.leng), nil /* right-align the data with sign extension (ARITHMETIC right shift) */
+			return int32(int64(u) >> (64 - r.leng)), nil /* right-align the data with sign extension (ARITHMETIC right shift) */
 		} else {
-			return int32(u), nil /* unsigned value -> return 'as is' */
+			return int32(u >> (64 - r.leng)), nil /* right-align the data, no sign extension */
 		}
 	} else {
-		/* register spanning multiple memory bytes but with an offset */
-		return 0, fmt.Errorf("ERROR: REGISTER SIZE AND OFFSET ARE NOT SUPPORTED\n")
+		return 0, fmt.Errorf("ERROR: REGISTER SIZE IS NOT SUPPORTED\n")
+	}
+}
+
+/* reg_bytes_to_u64 assembles a register file burst, least significant byte first */
+func reg_bytes_to_u64(b []byte) uint64 {
+	var u uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		u = uint64(b[i]) + (u << 8)
 	}
+	return u
 }
 
-func Lgw_connect(path string, spi_only bool, tx_notch_freq uint32) (*os.File, byte, byte, error) {
+func Lgw_connect(c *Concentrator, spi_only bool, tx_notch_freq uint32) (err error) {
+	/* close the link of a previous connection (Connect then Start, restart), its close error doesn't matter any more */
+	Lgw_disconnect(c)
 
-	var lgw_spi_mux_mode, lgw_spi_mux_target byte
 	/* open the SPI link */
-	c, err := Lgw_spi_open(path)
+	t, err := c.open()
 	if err != nil {
-		return nil, lgw_spi_mux_mode, lgw_spi_mux_target, err
+		return err
 	}
+	c.transport = t
+
+	/* don't keep a link to a board that failed to connect */
+	defer func() {
+		if err != nil {
+			Lgw_disconnect(c)
+		}
+	}()
+	c.spi_mux_mode = LGW_SPI_MUX_MODE0
+	c.fpga = nil
+	c.tx_notch_support = 0
+	c.tx_notch_offset = 0
+	c.page = -1
+	c.mcu_reg_ctrl = false
 
 	if spi_only == false {
 		/* Detect if the gateway has an FPGA with SPI mux header support */
 		/* First, we assume there is an FPGA, and try to read its version */
-		u, err := Lgw_spi_r(c, LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_FPGA, loregs[LGW_VERSION].addr)
+		u, err := c.transport.Spi_r(LGW_SPI_MUX_MODE1, LGW_SPI_MUX_TARGET_FPGA, loregs[LGW_VERSION].addr)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, lgw_spi_mux_target, err
+			return err
 		}
 
 		if Check_fpga_version(u) != true {
 			/* We failed to read expected FPGA version, so let's assume there is no FPGA */
 			log.Printf("INFO: no FPGA detected or version not supported (v%d)\n", u)
-			lgw_spi_mux_mode = LGW_SPI_MUX_MODE0
-			lgw_spi_mux_target = LGW_SPI_MUX_TARGET_SX1301
+			c.spi_mux_mode = LGW_SPI_MUX_MODE0
 		} else {
 			fmt.Printf("INFO: detected FPGA with SPI mux header (v%d)\n", u)
-			lgw_spi_mux_mode = LGW_SPI_MUX_MODE1
-			lgw_spi_mux_target = LGW_SPI_MUX_TARGET_FPGA
+			c.spi_mux_mode = LGW_SPI_MUX_MODE1
 			/* FPGA Soft Reset */
-			Lgw_spi_w(c, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_FPGA, 0, 1)
-			Lgw_spi_w(c, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_FPGA, 0, 0)
+			c.transport.Spi_w(c.spi_mux_mode, LGW_SPI_MUX_TARGET_FPGA, 0, 1)
+			c.transport.Spi_w(c.spi_mux_mode, LGW_SPI_MUX_TARGET_FPGA, 0, 0)
 			/* FPGA configure */
-			err := Lgw_fpga_configure(c, tx_notch_freq)
+			info, err := Lgw_fpga_configure(c, tx_notch_freq)
 			if err != nil {
-				return nil, lgw_spi_mux_mode, lgw_spi_mux_target, err
+				return err
 			}
-			return c, lgw_spi_mux_mode, lgw_spi_mux_target, nil
+			c.fpga = info
 		}
 
 		/* check SX1301 version */
-		u, err = Lgw_spi_r(c, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, loregs[LGW_VERSION].addr)
+		u, err = c.transport.Spi_r(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, loregs[LGW_VERSION].addr)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, lgw_spi_mux_target, fmt.Errorf("ERROR READING CHIP VERSION REGISTER\n")
+			return fmt.Errorf("ERROR READING CHIP VERSION REGISTER\n")
 		}
 		if u != byte(loregs[LGW_VERSION].dflt) {
-			return nil, lgw_spi_mux_mode, lgw_spi_mux_target, fmt.Errorf("ERROR: NOT EXPECTED CHIP VERSION (v%d)\n", u)
+			return fmt.Errorf("ERROR: NOT EXPECTED CHIP VERSION (v%d)\n", u)
 		}
 
 		/* write 0 to the page/reset register */
-		err = Lgw_spi_w(c, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, loregs[LGW_PAGE_REG].addr, 0)
+		err = c.transport.Spi_w(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, loregs[LGW_PAGE_REG].addr, 0)
 		if err != nil {
-			return nil, lgw_spi_mux_mode, lgw_spi_mux_target, fmt.Errorf("ERROR WRITING PAGE REGISTER\n")
+			return fmt.Errorf("ERROR WRITING PAGE REGISTER\n")
 		}
+		c.page = 0
 	}
 
 	fmt.Printf("Note: success connecting the concentrator\n")
-	return c, lgw_spi_mux_mode, lgw_spi_mux_target, nil
+	return nil
 }
 
-func Lgw_disconnect(f *os.File) error {
-	err := Lgw_spi_close(f)
+func Lgw_disconnect(c *Concentrator) error {
+	if c.transport == nil {
+		return nil
+	}
+	if c.borrowed {
+		/* the caller's Transport, opened again as is by the next connect */
+		c.transport = nil
+		return nil
+	}
+	err := c.transport.Close()
+	c.transport = nil
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func Lgw_soft_reset(f *os.File, lgw_spi_mux_mode byte) error {
+func Lgw_soft_reset(c *Concentrator) error {
 	/* check if SPI is initialised */
-	err := Lgw_spi_w(f, lgw_spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, 0, 0x80) /* 1 -> SOFT_RESET bit */
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	/* the reset selects page 0 and gives the registers back to the host, the cached page is forgotten anyway */
+	c.page = -1
+	c.mcu_reg_ctrl = false
+	err := c.transport.Spi_w(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, 0, 0x80) /* 1 -> SOFT_RESET bit */
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-/* register verification */
-func Lgw_reg_check(c *os.File, spi_mux_mode, spi_mux_target byte) error {
-	r := Lgw_reg_s{}
-	ok_msg := "+++MATCH+++"
-	notok_msg := "###MISMATCH###"
+/* Lgw_reg_check_entry_s is one register compared with its default value */
+type Lgw_reg_check_entry_s struct {
+	Fpga     bool   `json:"fpga"`
+	Name     string `json:"name"`
+	Expected int32  `json:"expected"`
+	Read     int32  `json:"read"`
+	Pass     bool   `json:"pass"`
+}
+
+/* Lgw_bist_result_s is the status of one RAM after a run of its BIST engine */
+type Lgw_bist_result_s struct {
+	Engine int    `json:"engine"` /* 0 or 1 */
+	Name   string `json:"name"`   /* name of the *_BIST_STATUS register */
+	Pass   bool   `json:"pass"`
+}
+
+/* Lgw_reg_check_s is the report of Lgw_reg_check */
+type Lgw_reg_check_s struct {
+	Registers []Lgw_reg_check_entry_s `json:"registers"`
+	Bist      []Lgw_bist_result_s     `json:"bist,omitempty"`
+}
+
+/* Pass tells if every register holds its default value and every BIST passed */
+func (r *Lgw_reg_check_s) Pass() bool {
+	for _, e := range r.Registers {
+		if !e.Pass {
+			return false
+		}
+	}
+	for _, b := range r.Bist {
+		if !b.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+/* free running counter, never at its default */
+var reg_check_skip = map[Lgw_reg_id]bool{
+	LGW_TIMESTAMP: true,
+}
+
+/* FPGA registers identifying the FPGA image, their table default is meaningless */
+var fpga_reg_check_skip = map[Lgw_fpga_reg_id]bool{
+	LGW_FPGA_FEATURE:          true,
+	LGW_FPGA_LBT_INITIAL_FREQ: true,
+	LGW_FPGA_VERSION:          true,
+	LGW_FPGA_HISTO_RAM_DATA:   true,
+}
 
-	fmt.Print("Start of register verification\n")
-	for i := uint16(0); i < LGW_TOTALREGS; i++ {
-		r = loregs[i]
-		read_value, err := Lgw_reg_r(c, spi_mux_mode, spi_mux_target, uint16(i))
+/*
+register verification, every register is compared with its default value, so it is meant to run on a board
+that was just reset (connected, not started), the FPGA registers set at connection are compared with their setting.
+The page pointer, the memory data ports, the timestamp counter and the FPGA identification registers are not checked. With bist, the BIST engines are run afterwards, they overwrite the SX1301 RAMs.
+*/
+func Lgw_reg_check(c *Concentrator, bist bool) (*Lgw_reg_check_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	report := &Lgw_reg_check_s{}
+
+	for i := Lgw_reg_id(0); i < LGW_TOTALREGS; i++ {
+		if reg_snapshot_skip[i] || reg_check_skip[i] {
+			continue
+		}
+		r := loregs[i]
+		read_value, err := Lgw_reg_r(c, i)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		ptr := ""
-		if read_value == r.dflt {
-			ptr = ok_msg
-		} else {
-			ptr = notok_msg
+		report.Registers = append(report.Registers, Lgw_reg_check_entry_s{Name: r.name, Expected: r.dflt, Read: read_value, Pass: read_value == r.dflt})
+	}
+	if c.spi_mux_mode == LGW_SPI_MUX_MODE1 {
+		for i := Lgw_fpga_reg_id(0); i < LGW_FPGA_TOTALREGS; i++ {
+			if fpga_reg_check_skip[i] {
+				continue
+			}
+			expected := fpga_reg_expected(c, i)
+			read_value, err := Lgw_fpga_reg_r(c, i)
+			if err != nil {
+				return nil, err
+			}
+			report.Registers = append(report.Registers, Lgw_reg_check_entry_s{Fpga: true, Name: fpga_regs[i].name, Expected: expected, Read: read_value, Pass: read_value == expected})
 		}
-		if r.sign == 1 {
-			fmt.Printf("%s reg number %d read: %d (%x) default: %d (%X)\n", ptr, i, read_value, read_value, r.dflt, r.dflt)
-		} else {
-			fmt.Printf("%s reg number %d read: %d (%x) default: %d (%X)\n", ptr, i, read_value, read_value, r.dflt, r.dflt)
+	}
+
+	if bist {
+		results, err := Lgw_bist(c)
+		if err != nil {
+			return nil, err
 		}
+		report.Bist = results
 	}
-	fmt.Print("End of register verification\n")
+	return report, nil
+}
 
-	return nil
+/* fpga_reg_expected is the default value of an FPGA register, or the value Lgw_fpga_configure set at connection */
+func fpga_reg_expected(c *Concentrator, register_id Lgw_fpga_reg_id) int32 {
+	switch register_id {
+	case LGW_FPGA_CTRL_INPUT_SYNC_I, LGW_FPGA_CTRL_INPUT_SYNC_Q, LGW_FPGA_CTRL_INVERT_IQ:
+		return 1
+	case LGW_FPGA_CTRL_OUTPUT_SYNC:
+		return 0
+	case LGW_FPGA_NOTCH_FREQ_OFFSET:
+		if c.tx_notch_support == 1 {
+			return int32(c.tx_notch_offset)
+		}
+	}
+	return fpga_regs[register_id].dflt
+}
+
+/* control and end flags of the two BIST engines */
+var (
+	bist_start    = [2]Lgw_reg_id{LGW_START_BIST0, LGW_START_BIST1}
+	bist_clear    = [2]Lgw_reg_id{LGW_CLEAR_BIST0, LGW_CLEAR_BIST1}
+	bist_finished = [2]Lgw_reg_id{LGW_BIST0_FINISHED, LGW_BIST1_FINISHED}
+)
+
+/* bist_status returns the *_BIST_STATUS flags set by a BIST engine, the BIST1 ones belong to engine 1 */
+func bist_status(engine int) []Lgw_reg_id {
+	var ids []Lgw_reg_id
+	for i := LGW_MCU_AGC_PROG_RAM_BIST_STATUS; i <= LGW_DATA_MNGT_RAM_BIST1_STATUS; i++ {
+		if strings.HasSuffix(loregs[i].name, "BIST1_STATUS") == (engine == 1) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+/*
+Lgw_bist runs the two RAM BIST engines of the SX1301, a status flag at 1 marks a RAM that failed.
+The RAMs content is lost, so it refuses to run on a started concentrator.
+*/
+func Lgw_bist(c *Concentrator) ([]Lgw_bist_result_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.is_started {
+		return nil, fmt.Errorf("ERROR: BIST ERASES THE RAMS, STOP THE CONCENTRATOR FIRST\n")
+	}
+
+	var results []Lgw_bist_result_s
+	for engine := 0; engine < 2; engine++ {
+		/* clear the previous results and start the engine */
+		err := Lgw_reg_w(c, bist_clear[engine], 1)
+		if err != nil {
+			return nil, err
+		}
+		err = Lgw_reg_w(c, bist_clear[engine], 0)
+		if err != nil {
+			return nil, err
+		}
+		err = Lgw_reg_w(c, bist_start[engine], 1)
+		if err != nil {
+			return nil, err
+		}
+
+		/* wait for the end of the test */
+		deadline := time.Now().Add(BIST_TIMEOUT)
+		for {
+			val, err := Lgw_reg_r(c, bist_finished[engine])
+			if err != nil {
+				return nil, err
+			}
+			if val == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				Lgw_reg_w(c, bist_start[engine], 0)
+				return nil, fmt.Errorf("ERROR: BIST%d DID NOT FINISH (ARE THE SX1301 CLOCKS RUNNING?)\n", engine)
+			}
+			time.Sleep(BIST_POLL)
+		}
+
+		for _, id := range bist_status(engine) {
+			val, err := Lgw_reg_r(c, id)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, Lgw_bist_result_s{Engine: engine, Name: loregs[id].name, Pass: val == 0})
+		}
+
+		/* stop the engine and put its flags back to their defaults */
+		err = Lgw_reg_w(c, bist_start[engine], 0)
+		if err != nil {
+			return nil, err
+		}
+		err = Lgw_reg_w(c, bist_clear[engine], 1)
+		if err != nil {
+			return nil, err
+		}
+		err = Lgw_reg_w(c, bist_clear[engine], 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
 }
 
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
 
 /* Write to a register addressed by name */
-func Lgw_reg_w(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16, reg_value int32) error {
+func Lgw_reg_w(c *Concentrator, register_id Lgw_reg_id, reg_value int32) error {
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
 	r := Lgw_reg_s{}
 
 	/* check input parameters */
@@ -902,12 +1152,12 @@ func Lgw_reg_w(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16
 
 	/* intercept direct access to PAGE_REG & SOFT_RESET */
 	if register_id == LGW_PAGE_REG {
-		Page_switch(c, spi_mux_mode, byte(reg_value))
+		Page_switch(c, byte(reg_value))
 		return nil
 	} else if register_id == LGW_SOFT_RESET {
 		/* only reset if lsb is 1 */
 		if reg_value&0x01 != 0 {
-			err := Lgw_soft_reset(c, spi_mux_mode)
+			err := Lgw_soft_reset(c)
 			if err != nil {
 				return err
 			}
@@ -920,25 +1170,35 @@ func Lgw_reg_w(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16
 
 	/* reject write to read-only registers */
 	if r.rdon == 1 {
-		fmt.Errorf("ERROR: TRYING TO WRITE A READ-ONLY REGISTER\n")
+		return fmt.Errorf("ERROR: TRYING TO WRITE A READ-ONLY REGISTER\n")
 	}
 
 	/* select proper register page if needed */
 	if r.page != -1 {
-		Page_switch(c, spi_mux_mode, byte(r.page))
+		Page_switch(c, byte(r.page))
 	}
 
-	err := reg_w_align32(c, spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r, reg_value)
+	err := reg_w_align32(c.transport, c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, loregs[:], r, reg_value)
 	if err != nil {
 		return err
 	}
+
+	/* the page cache can't be trusted once the MCU had control of the registers */
+	if register_id == LGW_EMERGENCY_FORCE_HOST_CTRL {
+		c.mcu_reg_ctrl = (reg_value == 0)
+		c.page = -1
+	}
 	return nil
 }
 
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
 
 /* Read to a register addressed by name */
-func Lgw_reg_r(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16) (int32, error) {
+func Lgw_reg_r(c *Concentrator, register_id Lgw_reg_id) (int32, error) {
+	if c.transport == nil {
+		return 0, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
 	r := Lgw_reg_s{}
 
 	/* check input parameters */
@@ -951,10 +1211,10 @@ func Lgw_reg_r(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16
 
 	/* select proper register page if needed */
 	if r.page != -1 {
-		Page_switch(c, spi_mux_mode, byte(r.page))
+		Page_switch(c, byte(r.page))
 	}
 
-	val, err := reg_r_align32(c, spi_mux_mode, spi_mux_target, r)
+	val, err := reg_r_align32(c.transport, c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r)
 	if err != nil {
 		return 0, err
 	}
@@ -964,22 +1224,26 @@ func Lgw_reg_r(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
 
 /* Point to a register by name and do a burst write */
-func Lgw_reg_wb(fd *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16, data []byte) error {
+func Lgw_reg_wb(c *Concentrator, register_id Lgw_reg_id, data []byte) error {
+	if c.transport == nil {
+		return fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
 	/* get register struct from the struct array */
 	r := loregs[register_id]
 
 	/* reject write to read-only registers */
 	if r.rdon == 1 {
-		fmt.Errorf("ERROR: TRYING TO BURST WRITE A READ-ONLY REGISTER\n")
+		return fmt.Errorf("ERROR: TRYING TO BURST WRITE A READ-ONLY REGISTER\n")
 	}
 
 	/* select proper register page if needed */
 	if r.page != -1 {
-		Page_switch(fd, spi_mux_mode, byte(r.page))
+		Page_switch(c, byte(r.page))
 	}
 
 	/* do the burst write */
-	err := Lgw_spi_wb(fd, spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r.addr, data)
+	err := c.transport.Spi_wb(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r.addr, data)
 	if err != nil {
 		return err
 	}
@@ -989,17 +1253,21 @@ func Lgw_reg_wb(fd *os.File, spi_mux_mode, spi_mux_target byte, register_id uint
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
 
 /* Point to a register by name and do a burst read */
-func Lgw_reg_rb(c *os.File, spi_mux_mode, spi_mux_target byte, register_id uint16, size uint16) ([]byte, error) {
+func Lgw_reg_rb(c *Concentrator, register_id Lgw_reg_id, size uint16) ([]byte, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+
 	/* get register struct from the struct array */
 	r := loregs[register_id]
 
 	/* select proper register page if needed */
 	if r.page != -1 {
-		Page_switch(c, spi_mux_mode, byte(r.page))
+		Page_switch(c, byte(r.page))
 	}
 
 	/* do the burst read */
-	val, err := Lgw_spi_rb(c, spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r.addr, size)
+	val, err := c.transport.Spi_rb(c.spi_mux_mode, LGW_SPI_MUX_TARGET_SX1301, r.addr, size)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,117 @@
+package liblorago
+
+import (
+	"fmt"
+	"testing"
+)
+
+/* reg_mem is a register file behind a Transport, it counts the reads and writes */
+type reg_mem struct {
+	mem    [256]byte
+	reads  int
+	writes int
+}
+
+func (m *reg_mem) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	m.writes++
+	m.mem[address] = data
+	return nil
+}
+
+func (m *reg_mem) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	m.reads++
+	return m.mem[address], nil
+}
+
+func (m *reg_mem) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	m.writes++
+	copy(m.mem[address:], data)
+	return nil
+}
+
+func (m *reg_mem) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	m.reads++
+	return append([]byte{}, m.mem[address:int(address)+int(size)]...), nil
+}
+
+func (m *reg_mem) Close() error {
+	return nil
+}
+
+/*
+every (offs, leng) round-trips with sign extension, and leaves the bits of the registers sharing its
+first and last bytes as they were
+*/
+func TestRegAlign32(t *testing.T) {
+	const addr = 16
+	for offs := uint8(0); offs < 8; offs++ {
+		for leng := uint8(1); leng <= 32; leng++ {
+			for _, sign := range []uint8{0, 1} {
+				r := Lgw_reg_s{0, addr, offs, sign, leng, 0, 0, "REG"}
+				last := addr + (offs+leng+7)/8 - 1
+				/* a register below r in its first byte, another above it in its last byte */
+				regs := []Lgw_reg_s{r, {0, addr, 0, 0, 8, 0, 0, "BELOW"}, {0, last, 0, 0, 8, 0, 0, "ABOVE"}}
+				mask := ((uint64(1) << leng) - 1) << offs
+				for _, v := range []int32{0, 1, -1, 0x5A5A5A5A, -0x5A5A5A5A} {
+					t.Run(fmt.Sprintf("offs%d/leng%d/sign%d/%d", offs, leng, sign, v), func(t *testing.T) {
+						m := &reg_mem{}
+						for i := range m.mem {
+							m.mem[i] = byte(0xC3 ^ i)
+						}
+						before := m.mem
+						err := reg_w_align32(m, LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, regs, r, v)
+						if err != nil {
+							t.Fatal(err)
+						}
+						got, err := reg_r_align32(m, LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, r)
+						if err != nil {
+							t.Fatal(err)
+						}
+						want := int32(uint32(v) & uint32(mask>>offs))
+						if (sign == 1) && (leng < 32) && (want&(1<<(leng-1)) != 0) {
+							want -= 1 << leng
+						}
+						if got != want {
+							t.Errorf("read %d, want %d", got, want)
+						}
+						for i := range m.mem {
+							keep := uint64(0xFF)
+							if (i >= addr) && (i <= int(last)) {
+								keep = ^(mask >> (8 * uint(i-addr))) & 0xFF
+							}
+							if (m.mem[i]^before[i])&byte(keep) != 0 {
+								t.Errorf("byte %d changed from %02X to %02X outside the register", i, before[i], m.mem[i])
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+/* an offset-0 register alone in its last byte is written in one burst, without reading the board */
+func TestRegAlign32DirectWrite(t *testing.T) {
+	for _, id := range []Lgw_reg_id{LGW_IF_FREQ_0, LGW_ADJUST_MODEM_START_OFFSET_RDX4, LGW_FSK_PATTERN_TIMEOUT_CFG, LGW_FSK_REF_PATTERN_LSB} {
+		m := &reg_mem{}
+		r := loregs[id]
+		err := reg_w_align32(m, LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, loregs[:], r, -3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (m.reads != 0) || (m.writes != 1) {
+			t.Errorf("%s: %d reads, %d writes, want a single write", r.name, m.reads, m.writes)
+		}
+		got, err := reg_r_align32(m, LGW_SPI_MUX_MODE0, LGW_SPI_MUX_TARGET_SX1301, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := int32(-3)
+		if r.sign == 0 {
+			want = int32(uint32(want) & (uint32(1)<<r.leng - 1))
+		}
+		if got != want {
+			t.Errorf("%s: read %d, want %d", r.name, got, want)
+		}
+	}
+}
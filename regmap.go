@@ -0,0 +1,138 @@
+package liblorago
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* Lgw_reg_id identifies a register of loregs, it prints as the name of its LGW_* constant */
+type Lgw_reg_id uint16
+
+/* Lgw_fpga_reg_id identifies a register of fpga_regs, it prints as the name of its LGW_FPGA_* constant */
+type Lgw_fpga_reg_id uint16
+
+func (id Lgw_reg_id) String() string {
+	if id >= LGW_TOTALREGS {
+		return fmt.Sprintf("Lgw_reg_id(%d)", uint16(id))
+	}
+	return loregs[id].name
+}
+
+func (id Lgw_fpga_reg_id) String() string {
+	if id >= LGW_FPGA_TOTALREGS {
+		return fmt.Sprintf("Lgw_fpga_reg_id(%d)", uint16(id))
+	}
+	return fpga_regs[id].name
+}
+
+/* RegisterByName returns the SX1301 register called name, the LGW_ prefix is optional and case is ignored */
+func RegisterByName(name string) (Lgw_reg_id, error) {
+	for i := range loregs {
+		if reg_name_match(loregs[i].name, name, "LGW_") {
+			return Lgw_reg_id(i), nil
+		}
+	}
+	return 0, fmt.Errorf("ERROR: UNKNOWN REGISTER %s\n", name)
+}
+
+/* FpgaRegisterByName returns the FPGA register called name, the LGW_FPGA_ prefix is optional and case is ignored */
+func FpgaRegisterByName(name string) (Lgw_fpga_reg_id, error) {
+	for i := range fpga_regs {
+		if reg_name_match(fpga_regs[i].name, name, "LGW_", "LGW_FPGA_") {
+			return Lgw_fpga_reg_id(i), nil
+		}
+	}
+	return 0, fmt.Errorf("ERROR: UNKNOWN FPGA REGISTER %s\n", name)
+}
+
+func reg_name_match(reg_name, name string, prefixes ...string) bool {
+	if strings.EqualFold(reg_name, name) {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.EqualFold(reg_name, p+name) {
+			return true
+		}
+	}
+	return false
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+/* Lgw_reg_dump_s is one register as read from the board */
+type Lgw_reg_dump_s struct {
+	Name    string `json:"name"`
+	Page    int8   `json:"page"` /* -1 for all pages */
+	Addr    uint8  `json:"addr"`
+	Offs    uint8  `json:"offs"`
+	Leng    uint8  `json:"leng"`
+	Signed  bool   `json:"signed"`
+	Rdon    bool   `json:"read_only"`
+	Value   int32  `json:"value"`
+	Default int32  `json:"default"`
+	Skipped bool   `json:"skipped"` /* not read, Value is 0: a data port, reading it would consume the memory behind */
+}
+
+func reg_dump_entry(r Lgw_reg_s, value int32) Lgw_reg_dump_s {
+	return Lgw_reg_dump_s{
+		Name:    r.name,
+		Page:    r.page,
+		Addr:    r.addr,
+		Offs:    r.offs,
+		Leng:    r.leng,
+		Signed:  r.sign == 1,
+		Rdon:    r.rdon == 1,
+		Value:   value,
+		Default: r.dflt,
+	}
+}
+
+/*
+Lgw_reg_dump reads every SX1301 register, in the order of their IDs. The registers snapshots leave out
+are not read, so a dump doesn't disturb a running board (e.g. eat the RX FIFO), their entry is Skipped.
+*/
+func Lgw_reg_dump(c *Concentrator) ([]Lgw_reg_dump_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	dump := make([]Lgw_reg_dump_s, 0, LGW_TOTALREGS)
+	for i := Lgw_reg_id(0); i < LGW_TOTALREGS; i++ {
+		if reg_snapshot_skip[i] {
+			d := reg_dump_entry(loregs[i], 0)
+			d.Skipped = true
+			dump = append(dump, d)
+			continue
+		}
+		val, err := Lgw_reg_r(c, i)
+		if err != nil {
+			return nil, err
+		}
+		dump = append(dump, reg_dump_entry(loregs[i], val))
+	}
+	return dump, nil
+}
+
+/* Lgw_fpga_reg_dump reads every FPGA register, in the order of their IDs, skipping those snapshots leave out */
+func Lgw_fpga_reg_dump(c *Concentrator) ([]Lgw_reg_dump_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return nil, fmt.Errorf("ERROR: NO FPGA\n")
+	}
+	dump := make([]Lgw_reg_dump_s, 0, LGW_FPGA_TOTALREGS)
+	for i := Lgw_fpga_reg_id(0); i < LGW_FPGA_TOTALREGS; i++ {
+		if fpga_reg_snapshot_skip[i] {
+			d := reg_dump_entry(fpga_regs[i], 0)
+			d.Skipped = true
+			dump = append(dump, d)
+			continue
+		}
+		val, err := Lgw_fpga_reg_r(c, i)
+		if err != nil {
+			return nil, err
+		}
+		dump = append(dump, reg_dump_entry(fpga_regs[i], val))
+	}
+	return dump, nil
+}
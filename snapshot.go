@@ -0,0 +1,221 @@
+package liblorago
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+/* Lgw_reg_snapshot_s holds the value of every register of a board, keyed by register name */
+type Lgw_reg_snapshot_s struct {
+	Sx1301 map[string]int32 `json:"sx1301"`
+	Fpga   map[string]int32 `json:"fpga,omitempty"` /* nil without FPGA */
+}
+
+/* Lgw_reg_diff_s is a register that differs between two snapshots */
+type Lgw_reg_diff_s struct {
+	Fpga bool   `json:"fpga"`
+	Name string `json:"name"`
+	A    int32  `json:"a"`
+	B    int32  `json:"b"`
+	In_a bool   `json:"in_a"` /* false if snapshot a lacks the register */
+	In_b bool   `json:"in_b"`
+}
+
+/*
+the page pointer and the data ports of the SX1301 memories are left out of snapshots,
+reading a data port moves the memory pointer and writing it fills the memory
+*/
+var reg_snapshot_skip = map[Lgw_reg_id]bool{
+	LGW_PAGE_REG:         true,
+	LGW_RX_DATA_BUF_DATA: true,
+	LGW_TX_DATA_BUF_DATA: true,
+	LGW_CAPTURE_RAM_DATA: true,
+	LGW_MCU_PROM_DATA:    true,
+}
+
+var fpga_reg_snapshot_skip = map[Lgw_fpga_reg_id]bool{
+	LGW_FPGA_HISTO_RAM_DATA: true,
+}
+
+/*
+registers a restore never writes, writing them triggers an action instead of setting a state:
+a write of the FIFO count pops the RX FIFO, of the radio select sends an AGC command, of the MCU resets
+stops or restarts the firmwares of a running board
+*/
+var reg_restore_skip = map[Lgw_reg_id]bool{
+	LGW_SOFT_RESET:                     true,
+	LGW_START_BIST0:                    true,
+	LGW_START_BIST1:                    true,
+	LGW_CLEAR_BIST0:                    true,
+	LGW_CLEAR_BIST1:                    true,
+	LGW_TX_TRIG_IMMEDIATE:              true,
+	LGW_TX_TRIG_DELAYED:                true,
+	LGW_TX_TRIG_GPS:                    true,
+	LGW_TX_TRIG_ALL:                    true,
+	LGW_CAPTURE_START:                  true,
+	LGW_CAPTURE_FORCE_TRIGGER:          true,
+	LGW_SPI_RADIO_A__DATA:              true,
+	LGW_SPI_RADIO_A__ADDR:              true,
+	LGW_SPI_RADIO_A__CS:                true,
+	LGW_SPI_RADIO_B__DATA:              true,
+	LGW_SPI_RADIO_B__ADDR:              true,
+	LGW_SPI_RADIO_B__CS:                true,
+	LGW_RX_DATA_BUF_ADDR:               true,
+	LGW_TX_DATA_BUF_ADDR:               true,
+	LGW_CAPTURE_RAM_ADDR:               true,
+	LGW_MCU_PROM_ADDR:                  true,
+	LGW_EMERGENCY_FORCE_HOST_CTRL:      true,
+	LGW_RX_PACKET_DATA_FIFO_NUM_STORED: true,
+	LGW_RADIO_SELECT:                   true,
+	LGW_MCU_RST_0:                      true,
+	LGW_MCU_RST_1:                      true,
+}
+
+/* the feature start restarts or stops the LBT and histogram state machines */
+var fpga_reg_restore_skip = map[Lgw_fpga_reg_id]bool{
+	LGW_FPGA_SOFT_RESET:           true,
+	LGW_FPGA_CTRL_CLEAR_HISTO_MEM: true,
+	LGW_FPGA_HISTO_RAM_ADDR:       true,
+	LGW_FPGA_CTRL_FEATURE_START:   true,
+}
+
+/* Lgw_reg_snapshot reads every SX1301 register, and every FPGA register if the board has one */
+func Lgw_reg_snapshot(c *Concentrator) (*Lgw_reg_snapshot_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	snap := &Lgw_reg_snapshot_s{Sx1301: make(map[string]int32)}
+	for i := Lgw_reg_id(0); i < LGW_TOTALREGS; i++ {
+		if reg_snapshot_skip[i] {
+			continue
+		}
+		val, err := Lgw_reg_r(c, i)
+		if err != nil {
+			return nil, err
+		}
+		snap.Sx1301[loregs[i].name] = val
+	}
+	if c.spi_mux_mode == LGW_SPI_MUX_MODE1 {
+		snap.Fpga = make(map[string]int32)
+		for i := Lgw_fpga_reg_id(0); i < LGW_FPGA_TOTALREGS; i++ {
+			if fpga_reg_snapshot_skip[i] {
+				continue
+			}
+			val, err := Lgw_fpga_reg_r(c, i)
+			if err != nil {
+				return nil, err
+			}
+			snap.Fpga[fpga_regs[i].name] = val
+		}
+	}
+	return snap, nil
+}
+
+func (s *Lgw_reg_snapshot_s) Save(path string) error {
+	buff, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buff, 0644)
+}
+
+func Lgw_reg_snapshot_load(path string) (*Lgw_reg_snapshot_s, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snap := &Lgw_reg_snapshot_s{}
+	err = json.Unmarshal(buff, snap)
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+/* Lgw_reg_snapshot_diff lists the registers of a and b that differ, SX1301 first, sorted by name */
+func Lgw_reg_snapshot_diff(a, b *Lgw_reg_snapshot_s) []Lgw_reg_diff_s {
+	diff := reg_map_diff(false, a.Sx1301, b.Sx1301)
+	return append(diff, reg_map_diff(true, a.Fpga, b.Fpga)...)
+}
+
+func reg_map_diff(fpga bool, a, b map[string]int32) []Lgw_reg_diff_s {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff []Lgw_reg_diff_s
+	for _, name := range names {
+		va, in_a := a[name]
+		vb, in_b := b[name]
+		if in_a && in_b && (va == vb) {
+			continue
+		}
+		diff = append(diff, Lgw_reg_diff_s{Fpga: fpga, Name: name, A: va, B: vb, In_a: in_a, In_b: in_b})
+	}
+	return diff
+}
+
+/*
+Lgw_reg_restore writes back the writable registers of snap that differ from the board, in register order.
+Read-only registers, data ports, memory pointers and registers that trigger an action are never written.
+It returns the number of registers written.
+*/
+func Lgw_reg_restore(c *Concentrator, snap *Lgw_reg_snapshot_s) (int, error) {
+	if c.transport == nil {
+		return 0, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if (snap.Fpga != nil) && (c.spi_mux_mode != LGW_SPI_MUX_MODE1) {
+		return 0, fmt.Errorf("ERROR: SNAPSHOT HAS FPGA REGISTERS BUT THE BOARD HAS NO FPGA\n")
+	}
+
+	nb := 0
+	for i := Lgw_reg_id(0); i < LGW_TOTALREGS; i++ {
+		r := loregs[i]
+		val, ok := snap.Sx1301[r.name]
+		if !ok || (r.rdon == 1) || reg_snapshot_skip[i] || reg_restore_skip[i] {
+			continue
+		}
+		cur, err := Lgw_reg_r(c, i)
+		if err != nil {
+			return nb, err
+		}
+		if cur == val {
+			continue
+		}
+		err = Lgw_reg_w(c, i, val)
+		if err != nil {
+			return nb, err
+		}
+		nb++
+	}
+
+	for i := Lgw_fpga_reg_id(0); (snap.Fpga != nil) && (i < LGW_FPGA_TOTALREGS); i++ {
+		r := fpga_regs[i]
+		val, ok := snap.Fpga[r.name]
+		if !ok || (r.rdon == 1) || fpga_reg_snapshot_skip[i] || fpga_reg_restore_skip[i] {
+			continue
+		}
+		cur, err := Lgw_fpga_reg_r(c, i)
+		if err != nil {
+			return nb, err
+		}
+		if cur == val {
+			continue
+		}
+		err = Lgw_fpga_reg_w(c, i, val)
+		if err != nil {
+			return nb, err
+		}
+		nb++
+	}
+	return nb, nil
+}
@@ -0,0 +1,53 @@
+package liblorago
+
+import (
+	"bytes"
+	"testing"
+)
+
+/* restoring a snapshot on a running board keeps its RX FIFO, firmwares and FPGA features as they are */
+func TestRestoreReceive(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		c, e := emulated(t, with_fpga)
+		snap, err := Lgw_reg_snapshot(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		/* a snapshot taken with the MCUs in reset and the FPGA features stopped */
+		snap.Sx1301["LGW_MCU_RST_0"] = 1
+		snap.Sx1301["LGW_MCU_RST_1"] = 1
+		var feature_start int32
+		if with_fpga {
+			feature_start, err = Lgw_fpga_reg_r(c, LGW_FPGA_CTRL_FEATURE_START)
+			if err != nil {
+				t.Fatal(err)
+			}
+			snap.Fpga["LGW_FPGA_CTRL_FEATURE_START"] = 1 - feature_start
+		}
+
+		e.Inject_lora_packet(0, 7, CR_LORA_4_5, true, 100, 7.5, 123456, []byte("hello"))
+		_, err = Lgw_reg_restore(c, snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Lgw_receive(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != 1 || !bytes.Equal(p[0].Payload[:p[0].Size], []byte("hello")) {
+			t.Errorf("received %d packets after a restore: %+v", len(p), p)
+		}
+		for _, r := range []Lgw_reg_id{LGW_MCU_RST_0, LGW_MCU_RST_1} {
+			v, err := Lgw_reg_r(c, r)
+			if err != nil || v != 0 {
+				t.Errorf("%s %d, %v after a restore", r, v, err)
+			}
+		}
+		if with_fpga {
+			v, err := Lgw_fpga_reg_r(c, LGW_FPGA_CTRL_FEATURE_START)
+			if err != nil || v != feature_start {
+				t.Errorf("FPGA feature start %d, %v after a restore, was %d", v, err, feature_start)
+			}
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package liblorago
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	SPECTRAL_SCAN_RSSI_RANGE = 256 /* histogram bins, 1 bin = -0.5 dBm */
+	SPECTRAL_SCAN_TIMEOUT    = 30 * time.Second
+	SPECTRAL_SCAN_POLL       = 100 * time.Millisecond
+)
+
+/* Spectral_scan_s is the RSSI histogram measured on one frequency */
+type Spectral_scan_s struct {
+	Freq_hz uint32
+	Rssi    [SPECTRAL_SCAN_RSSI_RANGE]float64 /* RSSI of each bin, in dBm */
+	Count   [SPECTRAL_SCAN_RSSI_RANGE]uint16  /* number of RSSI reads that fell in each bin */
+}
+
+/*
+Lgw_spectral_scan sweeps from start_freq to stop_freq by step_freq with the SX127x, and returns
+the RSSI histogram of nb_read reads at each frequency. It needs a connected board with an FPGA
+supporting the spectral scan, and must not be used while LBT runs on the same SX127x.
+*/
+func Lgw_spectral_scan(c *Concentrator, start_freq, stop_freq, step_freq uint32, nb_read uint16, rssi_offset int8) ([]Spectral_scan_s, error) {
+	if c.transport == nil {
+		return nil, fmt.Errorf("ERROR: CONCENTRATOR UNCONNECTED\n")
+	}
+	if c.spi_mux_mode != LGW_SPI_MUX_MODE1 {
+		return nil, fmt.Errorf("ERROR: SPECTRAL SCAN REQUIRES AN FPGA\n")
+	}
+	if (step_freq == 0) || (stop_freq < start_freq) {
+		return nil, fmt.Errorf("ERROR: INVALID SPECTRAL SCAN FREQUENCY RANGE\n")
+	}
+	if nb_read == 0 {
+		return nil, fmt.Errorf("ERROR: INVALID NUMBER OF RSSI READS\n")
+	}
+
+	/* Check if FPGA supports Spectral Scan */
+	val, err := Lgw_fpga_reg_r(c, LGW_FPGA_FEATURE)
+	if err != nil {
+		return nil, err
+	}
+	if TAKE_N_BITS_FROM(byte(val), 1, 1) == 0 {
+		return nil, fmt.Errorf("ERROR: No support for Spectral Scan in FPGA\n")
+	}
+
+	/* Configure SX127x for FSK, the FPGA then drives its frequency */
+	err = Lgw_setup_sx127x(c, start_freq, MOD_FSK, LGW_SX127X_RXBW_100K_HZ, rssi_offset, LGW_RADIO_TYPE_NONE)
+	if err != nil {
+		return nil, err
+	}
+
+	/* Configure the histogram */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_FEATURE_START, 0)
+	if err != nil {
+		return nil, err
+	}
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_HISTO_NB_READ, int32(nb_read))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Spectral_scan_s, 0, (stop_freq-start_freq)/step_freq+1)
+	for freq := start_freq; freq <= stop_freq; freq += step_freq {
+		r, err := spectral_scan_freq(c, freq)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+		if freq+step_freq < freq {
+			break /* wrapped */
+		}
+	}
+	return results, nil
+}
+
+func spectral_scan_freq(c *Concentrator, freq uint32) (Spectral_scan_s, error) {
+	r := Spectral_scan_s{Freq_hz: freq}
+
+	/* Set the frequency to scan */
+	freq_reg := (uint64(freq) << 19) / SX127X_XTAL_FREQ
+	err := Lgw_fpga_reg_w(c, LGW_FPGA_HISTO_SCAN_FREQ, int32(freq_reg))
+	if err != nil {
+		return r, err
+	}
+
+	/* Start histogram */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_FEATURE_START, 1)
+	if err != nil {
+		return r, err
+	}
+
+	/* Wait for histogram */
+	deadline := time.Now().Add(SPECTRAL_SCAN_TIMEOUT)
+	for {
+		val, err := Lgw_fpga_reg_r(c, LGW_FPGA_STATUS)
+		if err != nil {
+			return r, err
+		}
+		if TAKE_N_BITS_FROM(byte(val), 5, 1) == 1 {
+			break /* histogram is done */
+		}
+		if time.Now().After(deadline) {
+			return r, fmt.Errorf("ERROR: SPECTRAL SCAN TIMEOUT ON %d Hz\n", freq)
+		}
+		time.Sleep(SPECTRAL_SCAN_POLL)
+	}
+
+	/* Stop histogram */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_FEATURE_START, 0)
+	if err != nil {
+		return r, err
+	}
+
+	/* Read histogram */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_ACCESS_HISTO_MEM, 1)
+	if err != nil {
+		return r, err
+	}
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_HISTO_RAM_ADDR, 0)
+	if err != nil {
+		return r, err
+	}
+	buff, err := Lgw_fpga_reg_rb(c, LGW_FPGA_HISTO_RAM_DATA, SPECTRAL_SCAN_RSSI_RANGE*2)
+	if err != nil {
+		return r, err
+	}
+
+	/* Clear histogram for the next frequency */
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_CLEAR_HISTO_MEM, 1)
+	if err != nil {
+		return r, err
+	}
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_CLEAR_HISTO_MEM, 0)
+	if err != nil {
+		return r, err
+	}
+	err = Lgw_fpga_reg_w(c, LGW_FPGA_CTRL_ACCESS_HISTO_MEM, 0)
+	if err != nil {
+		return r, err
+	}
+
+	for i := 0; i < SPECTRAL_SCAN_RSSI_RANGE; i++ {
+		r.Rssi[i] = -float64(i) / 2
+		r.Count[i] = uint16(buff[2*i]) | (uint16(buff[2*i+1]) << 8)
+	}
+	return r, nil
+}
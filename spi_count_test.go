@@ -0,0 +1,159 @@
+package liblorago
+
+import (
+	"testing"
+)
+
+/* spi_counter counts the transfers going to the Transport it wraps, and the messages they take, one per transfer */
+type spi_counter struct {
+	Transport
+	transfers   int
+	page_writes int /* writes of the page register */
+	messages    int
+}
+
+func (k *spi_counter) count(x Spi_transfer) {
+	k.messages++
+	k.transfers++
+	if (x.Target == LGW_SPI_MUX_TARGET_SX1301) && (x.Address == PAGE_ADDR) && (x.Read == nil) {
+		k.page_writes++
+	}
+}
+
+func (k *spi_counter) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	k.count(Spi_transfer{Mode: spi_mux_mode, Target: spi_mux_target, Address: address, Data: []byte{data}})
+	return k.Transport.Spi_w(spi_mux_mode, spi_mux_target, address, data)
+}
+
+func (k *spi_counter) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	k.count(Spi_transfer{Mode: spi_mux_mode, Target: spi_mux_target, Address: address, Read: []byte{0}})
+	return k.Transport.Spi_r(spi_mux_mode, spi_mux_target, address)
+}
+
+func (k *spi_counter) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	k.count(Spi_transfer{Mode: spi_mux_mode, Target: spi_mux_target, Address: address, Data: data})
+	return k.Transport.Spi_wb(spi_mux_mode, spi_mux_target, address, data)
+}
+
+func (k *spi_counter) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	k.count(Spi_transfer{Mode: spi_mux_mode, Target: spi_mux_target, Address: address, Read: make([]byte, size)})
+	return k.Transport.Spi_rb(spi_mux_mode, spi_mux_target, address, size)
+}
+
+func (k *spi_counter) reset() {
+	k.transfers = 0
+	k.page_writes = 0
+	k.messages = 0
+}
+
+/* spi_batch_counter also counts batches, as a Spidev would send them: one message each */
+type spi_batch_counter struct {
+	*spi_counter
+}
+
+func (k spi_batch_counter) Spi_batch(xfers []Spi_transfer) error {
+	for _, x := range xfers {
+		k.count(x)
+	}
+	k.messages -= len(xfers) - 1
+	return spi_batch(k.Transport, xfers)
+}
+
+/* count_start starts c, whose transport is k */
+func count_start(t *testing.T, k *spi_counter, c *Concentrator) {
+	t.Helper()
+	err := Lgw_start(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Lgw_stop(c)
+	})
+	t.Logf("start: %d transfers, %d page writes, %d messages", k.transfers, k.page_writes, k.messages)
+}
+
+/* count_receive receives 100 packets injected in e one by one */
+func count_receive(t *testing.T, k *spi_counter, c *Concentrator, e *Emulator) {
+	t.Helper()
+	k.reset()
+	for i := 0; i < 100; i++ {
+		e.Inject_lora_packet(0, 7, CR_LORA_4_5, true, 100, 7.5, 123456, []byte("hello"))
+		p, err := Lgw_receive(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != 1 {
+			t.Fatalf("got %d packets, want 1", len(p))
+		}
+	}
+	t.Logf("100 receives: %d transfers, %d page writes, %d messages", k.transfers, k.page_writes, k.messages)
+}
+
+/*
+The page cache keeps the page register writes of a start to about one per page change. Measured with this
+test without FPGA: 23 page writes out of 541 transfers, against 324 out of 842 with the cache disabled.
+Receiving only touches registers common to all pages.
+*/
+func TestSpiCountPageWrites(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		s, err := ParseConfig("testdata/global_conf.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := NewEmulator(with_fpga)
+		k := &spi_counter{Transport: e}
+		c := NewConcentratorTransport(k, s)
+
+		count_start(t, k, c)
+		if k.page_writes > 30 {
+			t.Errorf("start: %d page writes, want at most 30", k.page_writes)
+		}
+		count_receive(t, k, c, e)
+		if k.page_writes != 0 {
+			t.Errorf("100 receives: %d page writes, want none", k.page_writes)
+		}
+	})
+}
+
+/*
+Batching the RX FIFO drain takes a receive of one packet from 4 messages down to 3. Batching the modem
+configuration takes a start without FPGA from 531 messages down to 456, the radio writes are still sent
+one by one (batched, they saved about 150 more). Both starts leave the same registers.
+*/
+func TestSpiCountMessages(t *testing.T) {
+	boards(t, func(t *testing.T, with_fpga bool) {
+		var start, receive [2]int /* messages, one by one then batched */
+		var snap [2]*Lgw_reg_snapshot_s
+		for i, batch := range []bool{false, true} {
+			s, err := ParseConfig("testdata/global_conf.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := NewEmulator(with_fpga)
+			k := &spi_counter{Transport: e}
+			var tr Transport = k
+			if batch {
+				tr = spi_batch_counter{k}
+			}
+			c := NewConcentratorTransport(tr, s)
+
+			count_start(t, k, c)
+			start[i] = k.messages
+			snap[i], err = Lgw_reg_snapshot(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count_receive(t, k, c, e)
+			receive[i] = k.messages
+		}
+		for _, d := range Lgw_reg_snapshot_diff(snap[0], snap[1]) {
+			t.Errorf("%s is %d after a batched start, %d one by one", d.Name, d.B, d.A)
+		}
+		if start[0]-start[1] < 70 {
+			t.Errorf("start: %d messages batched, %d one by one, want at least 70 less", start[1], start[0])
+		}
+		if (receive[0] != 400) || (receive[1] != 300) {
+			t.Errorf("100 receives: %d messages batched, %d one by one, want 300 and 400", receive[1], receive[0])
+		}
+	})
+}
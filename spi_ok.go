@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"sync"
 	"syscall"
 	"unsafe"
@@ -31,10 +32,12 @@ const (
 	spiIOCRdMaxSpeedHz  = 0x80046B04
 	spiIOCMessage0      = 0x40006B00
 	spiIOCIncrementor   = 0x200000
+	spiCsHigh           = 0x04
 )
 
-var lock sync.Mutex = sync.Mutex{}
-var locks map[*os.File]*sync.Mutex = make(map[*os.File]*sync.Mutex, 0)
+const (
+	LGW_BATCH_MAX_XFERS = 64 /* spi_ioc_transfer in one SPI_IOC_MESSAGE, 2 per batched transfer */
+)
 
 type spiIOCTransfer struct {
 	txBuf       uint64
@@ -51,141 +54,263 @@ func spiIOCMessageN(n uint32) uint32 {
 	return (spiIOCMessage0 + (n * spiIOCIncrementor))
 }
 
-func Lgw_spi_open(path string) (*os.File, error) {
+/* Transport is what the register and FPGA layers use to reach the concentrator SPI bus */
+type Transport interface {
+	Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error
+	Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error)
+	Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error
+	Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error)
+	Close() error
+}
+
+/* Spi_transfer is one transfer of a batch, it writes Data or, when Read is set, reads len(Read) bytes into Read */
+type Spi_transfer struct {
+	Mode    byte /* LGW_SPI_MUX_MODE0 or LGW_SPI_MUX_MODE1 */
+	Target  byte
+	Address byte
+	Data    []byte
+	Read    []byte
+}
+
+/* Batcher is implemented by the transports able to submit several transfers at once */
+type Batcher interface {
+	Spi_batch(xfers []Spi_transfer) error
+}
+
+/* spi_batch runs xfers on t, in one go when t is a Batcher, one after the other otherwise */
+func spi_batch(t Transport, xfers []Spi_transfer) error {
+	if b, ok := t.(Batcher); ok {
+		return b.Spi_batch(xfers)
+	}
+	for i := range xfers {
+		x := &xfers[i]
+		var err error
+		switch {
+		case len(x.Read) == 1:
+			x.Read[0], err = t.Spi_r(x.Mode, x.Target, x.Address)
+		case x.Read != nil:
+			var b []byte
+			b, err = t.Spi_rb(x.Mode, x.Target, x.Address, uint16(len(x.Read)))
+			copy(x.Read, b)
+		case len(x.Data) == 1:
+			err = t.Spi_w(x.Mode, x.Target, x.Address, x.Data[0])
+		default:
+			err = t.Spi_wb(x.Mode, x.Target, x.Address, x.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Spi_options are the link parameters of a spidev device, a zero field selects the default */
+type Spi_options struct {
+	Speed_hz      uint32 /* SPI clock, SPI_SPEED by default */
+	Mode          byte   /* SPI mode 0 to 3, clock polarity and phase */
+	Bits_per_word byte   /* 8 by default */
+	Chunk_size    uint32 /* largest number of data bytes in one SPI message, headers excluded, LGW_BURST_CHUNK by default */
+	Cs_high       bool   /* chip select is active high */
+	Cs_delay_us   uint16 /* delay between the end of a transfer and the chip select release */
+}
+
+/* with_defaults fills the zero fields of o with the default values */
+func (o Spi_options) with_defaults() Spi_options {
+	if o.Speed_hz == 0 {
+		o.Speed_hz = SPI_SPEED
+	}
+	if o.Bits_per_word == 0 {
+		o.Bits_per_word = 8
+	}
+	if o.Chunk_size == 0 {
+		o.Chunk_size = LGW_BURST_CHUNK
+	}
+	return o
+}
+
+/* Spidev is the Transport for a concentrator wired to a linux spidev device */
+type Spidev struct {
+	lock sync.Mutex /* held by the methods, a burst or a batch is not interleaved with other transfers */
+	file *os.File
+	opts Spi_options
+}
+
+func (d *Spidev) Spi_w(spi_mux_mode, spi_mux_target, address, data byte) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return Lgw_spi_w(d, spi_mux_mode, spi_mux_target, address, data)
+}
+
+func (d *Spidev) Spi_r(spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return Lgw_spi_r(d, spi_mux_mode, spi_mux_target, address)
+}
+
+func (d *Spidev) Spi_wb(spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return Lgw_spi_wb(d, spi_mux_mode, spi_mux_target, address, data)
+}
+
+func (d *Spidev) Spi_rb(spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return Lgw_spi_rb(d, spi_mux_mode, spi_mux_target, address, size)
+}
+
+func (d *Spidev) Spi_batch(xfers []Spi_transfer) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return Lgw_spi_batch(d, xfers)
+}
+
+/* Options returns the link parameters the device was opened with, defaults filled in */
+func (d *Spidev) Options() Spi_options {
+	return d.opts
+}
+
+/*
+message sends k as one SPI_IOC_MESSAGE, with the clock and word size of the device on every transfer
+and the chip select delay on the transfers that end with a chip select release. It returns the number of bytes transferred.
+*/
+func (d *Spidev) message(k []spiIOCTransfer) (int, error) {
+	for i := range k {
+		k[i].speedHz = d.opts.Speed_hz
+		k[i].bitsPerWord = d.opts.Bits_per_word
+		if (k[i].csChange == 1) || (i == len(k)-1) {
+			k[i].delayus = d.opts.Cs_delay_us
+		}
+	}
+	return spi_ioc_message(d.file.Fd(), k)
+}
+
+/* spi_ioc_message is the SPI_IOC_MESSAGE ioctl, a variable so that tests can look at what goes on the wire */
+var spi_ioc_message = func(fd uintptr, k []spiIOCTransfer) (int, error) {
+	I, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(spiIOCMessageN(uint32(len(k)))), uintptr(unsafe.Pointer(&k[0])))
+	if errno != 0 {
+		err := syscall.Errno(errno)
+		return 0, err
+	}
+	return int(I), nil
+}
+
+func (d *Spidev) Close() error {
+	return Lgw_spi_close(d)
+}
+
+func Lgw_spi_open(path string) (*Spidev, error) {
+	return Lgw_spi_open_opts(path, Spi_options{})
+}
+
+/* Lgw_spi_open_opts opens the spidev at path with the link parameters of opts */
+func Lgw_spi_open_opts(path string, opts Spi_options) (d *Spidev, err error) {
+	opts = opts.with_defaults()
+	if opts.Mode > 3 {
+		return nil, fmt.Errorf("ERROR: INVALID SPI MODE %d\n", opts.Mode)
+	}
 	file, err := os.OpenFile(path, os.O_RDWR, 0)
 	if err != nil {
 		return nil, err
 	}
-	mode := 0
+	defer func() {
+		/* don't leak the file when the device refuses the settings */
+		if err != nil {
+			file.Close()
+		}
+	}()
+	mode := opts.Mode
+	if opts.Cs_high {
+		mode |= spiCsHigh
+	}
 	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCWrMode, uintptr(unsafe.Pointer(&mode)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
-	speed := SPI_SPEED
+	speed := opts.Speed_hz
 	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCWrMaxSpeedHz, uintptr(unsafe.Pointer(&speed)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
-	bpw := 8
+	bpw := opts.Bits_per_word
 	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCWrBitsPerWord, uintptr(unsafe.Pointer(&bpw)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
 	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCRdMode, uintptr(unsafe.Pointer(&mode)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
 	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCRdMaxSpeedHz, uintptr(unsafe.Pointer(&speed)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
 	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), spiIOCRdBitsPerWord, uintptr(unsafe.Pointer(&bpw)))
 	if errno != 0 {
-		err := syscall.Errno(errno)
-		return nil, err
+		return nil, syscall.Errno(errno)
 	}
-	locks[file] = &sync.Mutex{}
-
 	log.Print("Note: SPI port opened and configured ok\n")
-	return file, nil
+	return &Spidev{file: file, opts: opts}, nil
 }
 
-func Lgw_spi_close(f *os.File) error {
-	lock.Lock()
-	defer lock.Unlock()
-	locks[f].Lock()
-	defer locks[f].Unlock()
-	err := f.Close()
+/* Lgw_spi_close waits for the transfer in progress on d, if any, then closes it */
+func Lgw_spi_close(d *Spidev) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	err := d.file.Close()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func Lgw_spi_w(file *os.File, spi_mux_mode, spi_mux_target, address, data byte) error {
-	write := make([]byte, 0)
-
+/* spi_header builds the command bytes of a transfer: mux target (MODE1 only) then R/W bit and address */
+func spi_header(spi_mux_mode, spi_mux_target, access, address byte) []byte {
 	if (address & 0x80) != 0 {
 		fmt.Print("WARNING: SPI address > 127\n")
 	}
-
 	if spi_mux_mode == LGW_SPI_MUX_MODE1 {
-		write = append(write, []byte{0, 0, 0}...)
-		write[0] = spi_mux_target
-		write[1] = WRITE_ACCESS | (address & 0x7F)
-		write[2] = data
-	} else {
-		write = append(write, []byte{0, 0}...)
-		write[0] = WRITE_ACCESS | (address & 0x7F)
-		write[1] = data
+		return []byte{spi_mux_target, access | (address & 0x7F)}
 	}
+	return []byte{access | (address & 0x7F)}
+}
 
-	k := spiIOCTransfer{}
-	k.length = uint32(len(write))
-	k.txBuf = uint64(uintptr(unsafe.Pointer(&write[0])))
-	k.csChange = 0
-	//k.speedHz = SPI_SPEED
-	//k.bitsPerWord = 8
+func Lgw_spi_w(d *Spidev, spi_mux_mode, spi_mux_target, address, data byte) error {
+	write := append(spi_header(spi_mux_mode, spi_mux_target, WRITE_ACCESS, address), data)
 
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(spiIOCMessageN(1)), uintptr(unsafe.Pointer(&k)))
-	if errno != 0 {
-		err := syscall.Errno(errno)
+	k := make([]spiIOCTransfer, 1)
+	k[0].length = uint32(len(write))
+	k[0].txBuf = uint64(uintptr(unsafe.Pointer(&write[0])))
+	k[0].csChange = 0
+
+	_, err := d.message(k)
+	runtime.KeepAlive(write)
+	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func Lgw_spi_r(file *os.File, spi_mux_mode, spi_mux_target, address byte) (byte, error) {
-	write := make([]byte, 0)
-
-	if (address & 0x80) != 0 {
-		fmt.Print("WARNING: SPI address > 127\n")
-	}
-
-	if spi_mux_mode == LGW_SPI_MUX_MODE1 {
-		write = append(write, []byte{0, 0, 0}...)
-		write[0] = spi_mux_target
-		write[1] = READ_ACCESS | (address & 0x7F)
-	} else {
-		write = append(write, []byte{0, 0}...)
-		write[0] = READ_ACCESS | (address & 0x7F)
-	}
+func Lgw_spi_r(d *Spidev, spi_mux_mode, spi_mux_target, address byte) (byte, error) {
+	write := append(spi_header(spi_mux_mode, spi_mux_target, READ_ACCESS, address), 0)
 
 	read := make([]byte, len(write))
-	k := spiIOCTransfer{}
-	k.length = uint32(len(write))
-	k.txBuf = uint64(uintptr(unsafe.Pointer(&write[0])))
-	k.rxBuf = uint64(uintptr(unsafe.Pointer(&read[0])))
-	k.csChange = 0
-	k.speedHz = SPI_SPEED
-	k.bitsPerWord = 8
-
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(spiIOCMessageN(1)), uintptr(unsafe.Pointer(&k)))
-	if errno != 0 {
-		err := syscall.Errno(errno)
+	k := make([]spiIOCTransfer, 1)
+	k[0].length = uint32(len(write))
+	k[0].txBuf = uint64(uintptr(unsafe.Pointer(&write[0])))
+	k[0].rxBuf = uint64(uintptr(unsafe.Pointer(&read[0])))
+	k[0].csChange = 0
+
+	_, err := d.message(k)
+	runtime.KeepAlive(write)
+	if err != nil {
 		return 0, err
 	}
-	return read[1], nil
+	return read[len(write)-1], nil
 }
 
-func Lgw_spi_wb(file *os.File, spi_mux_mode, spi_mux_target, address byte, data []byte) error {
-	write := make([]byte, 0)
-	if (address & 0x80) != 0 {
-		fmt.Print("WARNING: SPI address > 127\n")
-	}
-	//if spi_mux_mode == LGW_SPI_MUX_MODE1 {
-	//	write = append(write, []byte{0, 0}...)
-	//	write[0] = spi_mux_target
-	//	write[1] = WRITE_ACCESS | (address & 0x7F)
-	//} else {
-	write = append(write, []byte{0}...)
-	write[0] = WRITE_ACCESS | (address & 0x7F)
-	//}
+func Lgw_spi_wb(d *Spidev, spi_mux_mode, spi_mux_target, address byte, data []byte) error {
+	write := spi_header(spi_mux_mode, spi_mux_target, WRITE_ACCESS, address)
 
 	size_to_do := uint32(len(data))
 	k := make([]spiIOCTransfer, 2)
@@ -194,16 +319,15 @@ func Lgw_spi_wb(file *os.File, spi_mux_mode, spi_mux_target, address byte, data
 	k[0].length = uint32(len(write))
 	byte_transfered := uint64(0)
 	for i := 0; size_to_do > 0; i++ {
-		chunk_size := uint32(LGW_BURST_CHUNK)
-		if size_to_do < LGW_BURST_CHUNK {
+		chunk_size := d.opts.Chunk_size
+		if size_to_do < d.opts.Chunk_size {
 			chunk_size = size_to_do
 		}
-		offset := uint32(i) * LGW_BURST_CHUNK
+		offset := uint32(i) * d.opts.Chunk_size
 		k[1].txBuf = uint64(uintptr(unsafe.Pointer(&data[0+offset])))
 		k[1].length = chunk_size
-		I, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(spiIOCMessageN(2)), uintptr(unsafe.Pointer(&k[0])))
-		if errno != 0 {
-			err := syscall.Errno(errno)
+		I, err := d.message(k)
+		if err != nil {
 			return err
 		}
 		byte_transfered += uint64(I) - uint64(k[0].length)
@@ -213,22 +337,9 @@ func Lgw_spi_wb(file *os.File, spi_mux_mode, spi_mux_target, address byte, data
 	return nil
 }
 
-func Lgw_spi_rb(file *os.File, spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
+func Lgw_spi_rb(d *Spidev, spi_mux_mode, spi_mux_target, address byte, size uint16) ([]byte, error) {
 	read := make([]byte, size)
-	write := make([]byte, 0)
-
-	if (address & 0x80) != 0 {
-		fmt.Print("WARNING: SPI address > 127\n")
-	}
-
-	if spi_mux_mode == LGW_SPI_MUX_MODE1 {
-		write = append(write, []byte{0, 0}...)
-		write[0] = spi_mux_target
-		write[1] = READ_ACCESS | (address & 0x7F)
-	} else {
-		write = append(write, []byte{0}...)
-		write[0] = READ_ACCESS | (address & 0x7F)
-	}
+	write := spi_header(spi_mux_mode, spi_mux_target, READ_ACCESS, address)
 
 	size_to_do := uint32(size)
 	k := make([]spiIOCTransfer, 2)
@@ -238,16 +349,15 @@ func Lgw_spi_rb(file *os.File, spi_mux_mode, spi_mux_target, address byte, size
 
 	byte_transfered := uint64(0)
 	for i := 0; size_to_do > 0; i++ {
-		chunk_size := uint32(LGW_BURST_CHUNK)
-		if size_to_do < LGW_BURST_CHUNK {
+		chunk_size := d.opts.Chunk_size
+		if size_to_do < d.opts.Chunk_size {
 			chunk_size = size_to_do
 		}
-		offset := uint32(i * LGW_BURST_CHUNK)
+		offset := uint32(i) * d.opts.Chunk_size
 		k[1].rxBuf = uint64(uintptr(unsafe.Pointer(&read[0+offset])))
 		k[1].length = chunk_size
-		I, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(spiIOCMessageN(2)), uintptr(unsafe.Pointer(&k[0])))
-		if errno != 0 {
-			err := syscall.Errno(errno)
+		I, err := d.message(k)
+		if err != nil {
 			return nil, err
 		}
 		byte_transfered += uint64(I) - uint64(k[0].length)
@@ -256,3 +366,85 @@ func Lgw_spi_rb(file *os.File, spi_mux_mode, spi_mux_target, address byte, size
 	}
 	return read, nil
 }
+
+/*
+Lgw_spi_batch sends xfers in as few SPI_IOC_MESSAGE(N) as possible, the chip select is released between
+two transfers as it is between two single transfers. A message holds at most Chunk_size data bytes, a transfer
+too big for one message is sent on its own, in chunks.
+*/
+func Lgw_spi_batch(d *Spidev, xfers []Spi_transfer) error {
+	k := make([]spiIOCTransfer, 0, LGW_BATCH_MAX_XFERS)
+	headers := make([][]byte, 0, LGW_BATCH_MAX_XFERS/2)
+	size := 0
+
+	flush := func() error {
+		if len(k) == 0 {
+			return nil
+		}
+		k[len(k)-1].csChange = 0 /* release the chip select at the end of the message */
+		_, err := d.message(k)
+		runtime.KeepAlive(headers)
+		k = k[:0]
+		headers = headers[:0]
+		size = 0
+		return err
+	}
+
+	for i := range xfers {
+		x := &xfers[i]
+		access := byte(WRITE_ACCESS)
+		length := len(x.Data)
+		if x.Read != nil {
+			access = READ_ACCESS
+			length = len(x.Read)
+		}
+		if length == 0 {
+			return fmt.Errorf("ERROR: BURST OF NULL LENGTH\n")
+		}
+		write := spi_header(x.Mode, x.Target, access, x.Address)
+
+		if length > int(d.opts.Chunk_size) {
+			err := flush()
+			if err != nil {
+				return err
+			}
+			if x.Read != nil {
+				b, err := Lgw_spi_rb(d, x.Mode, x.Target, x.Address, uint16(length))
+				if err != nil {
+					return err
+				}
+				copy(x.Read, b)
+			} else {
+				err = Lgw_spi_wb(d, x.Mode, x.Target, x.Address, x.Data)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if (len(k)+2 > LGW_BATCH_MAX_XFERS) || (size+length > int(d.opts.Chunk_size)) {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+
+		headers = append(headers, write)
+		h := spiIOCTransfer{}
+		h.txBuf = uint64(uintptr(unsafe.Pointer(&write[0])))
+		h.length = uint32(len(write))
+		data := spiIOCTransfer{}
+		data.length = uint32(length)
+		data.csChange = 1 /* end of this transfer */
+		if x.Read != nil {
+			data.rxBuf = uint64(uintptr(unsafe.Pointer(&x.Read[0])))
+		} else {
+			data.txBuf = uint64(uintptr(unsafe.Pointer(&x.Data[0])))
+		}
+		k = append(k, h, data)
+		size += length
+	}
+	err := flush()
+	runtime.KeepAlive(xfers)
+	return err
+}
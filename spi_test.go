@@ -0,0 +1,145 @@
+package liblorago
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+/* spi_buf is the buffer an spi_ioc_transfer points to */
+func spi_buf(p uint64, n int) []byte {
+	return unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&p))), n)
+}
+
+/*
+spi_wire replaces the ioctl for the duration of the test, it records the frames sent, a frame being the
+bytes clocked between a chip select and its release, and answers each byte with its position in its frame.
+*/
+func spi_wire(t *testing.T) *[][]byte {
+	frames := [][]byte{}
+	ioctl := spi_ioc_message
+	spi_ioc_message = func(fd uintptr, k []spiIOCTransfer) (int, error) {
+		n := 0
+		var frame []byte
+		for i := range k {
+			l := int(k[i].length)
+			if k[i].rxBuf != 0 {
+				rx := spi_buf(k[i].rxBuf, l)
+				for j := range rx {
+					rx[j] = byte(len(frame) + j)
+				}
+			}
+			if k[i].txBuf != 0 {
+				frame = append(frame, spi_buf(k[i].txBuf, l)...)
+			} else {
+				frame = append(frame, make([]byte, l)...)
+			}
+			n += l
+			if (k[i].csChange == 1) || (i == len(k)-1) {
+				frames = append(frames, frame)
+				frame = nil
+			}
+		}
+		return n, nil
+	}
+	t.Cleanup(func() {
+		spi_ioc_message = ioctl
+	})
+	return &frames
+}
+
+/* every access carries the mux target byte in MODE1, and only in MODE1, whatever the target and the access */
+func TestSpiMuxHeader(t *testing.T) {
+	const addr = 0x21
+	data := []byte{0xAA, 0xBB, 0xCC}
+	for _, mode := range []byte{LGW_SPI_MUX_MODE0, LGW_SPI_MUX_MODE1} {
+		for _, target := range []byte{LGW_SPI_MUX_TARGET_SX1301, LGW_SPI_MUX_TARGET_FPGA, LGW_SPI_MUX_TARGET_EEPROM, LGW_SPI_MUX_TARGET_SX127X} {
+			header := func(access byte) []byte {
+				if mode == LGW_SPI_MUX_MODE1 {
+					return []byte{target, access | addr}
+				}
+				return []byte{access | addr}
+			}
+			h := byte(len(header(READ_ACCESS))) /* position of the first data byte in a frame */
+			t.Run(fmt.Sprintf("mode%d/target%d", mode, target), func(t *testing.T) {
+				d := &Spidev{opts: Spi_options{}.with_defaults()}
+				frames := spi_wire(t)
+
+				err := d.Spi_w(mode, target, addr, data[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				v, err := d.Spi_r(mode, target, addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v != h {
+					t.Errorf("r returned the byte at %d of the frame, want %d", v, h)
+				}
+				err = d.Spi_wb(mode, target, addr, data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := d.Spi_rb(mode, target, addr, uint16(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(b, []byte{h, h + 1, h + 2}) {
+					t.Errorf("rb returned the bytes at % X of the frame, want from %d", b, h)
+				}
+				read := make([]byte, len(data))
+				err = d.Spi_batch([]Spi_transfer{
+					{Mode: mode, Target: target, Address: addr, Data: data},
+					{Mode: mode, Target: target, Address: addr, Read: read},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(read, []byte{h, h + 1, h + 2}) {
+					t.Errorf("batched rb returned the bytes at % X of the frame, want from %d", read, h)
+				}
+
+				want := [][]byte{
+					append(header(WRITE_ACCESS), data[0]),
+					append(header(READ_ACCESS), 0),
+					append(header(WRITE_ACCESS), data...),
+					append(header(READ_ACCESS), 0, 0, 0),
+					append(header(WRITE_ACCESS), data...),
+					append(header(READ_ACCESS), 0, 0, 0),
+				}
+				if len(*frames) != len(want) {
+					t.Fatalf("%d frames, want %d: % X", len(*frames), len(want), *frames)
+				}
+				for i, op := range []string{"w", "r", "wb", "rb", "batched wb", "batched rb"} {
+					if !bytes.Equal((*frames)[i], want[i]) {
+						t.Errorf("%s frame % X, want % X", op, (*frames)[i], want[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+/* a file that is not a spidev refuses the SPI settings, it must be closed */
+func TestSpiOpenNotSpidev(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spidev")
+	err := os.WriteFile(path, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip(err)
+	}
+	_, err = Lgw_spi_open(path)
+	if err == nil {
+		t.Fatal("a regular file opened as a spidev")
+	}
+	after, _ := os.ReadDir("/proc/self/fd")
+	if len(after) != len(fds) {
+		t.Errorf("%d file descriptors open, %d before", len(after), len(fds))
+	}
+}